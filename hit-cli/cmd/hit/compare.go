@@ -0,0 +1,60 @@
+package main
+
+import (
+	"effective-go/hit-cli/hit"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+)
+
+const compareUsageText = `
+Usage:
+  hit compare [options] old new
+
+Compares a run with a baseline run. The runs are JSON summaries
+(-o json) or results files (-save).
+Options:`
+
+// runCompare compares the results of two runs.
+func runCompare(s *flag.FlagSet, args []string, out io.Writer) error {
+	s.Usage = func() {
+		fmt.Fprintln(s.Output(), compareUsageText[1:])
+		s.PrintDefaults()
+	}
+	fail := s.Bool("fail", false, "Exit with an error if the new latencies are significantly worse")
+	if err := s.Parse(args); err != nil {
+		return err
+	}
+	if s.NArg() != 2 {
+		err := errors.New("requires an old and a new run")
+		fmt.Fprintln(s.Output(), err)
+		s.Usage()
+		return err
+	}
+
+	old, err := loadRun(s.Arg(0))
+	if err != nil {
+		return err
+	}
+	run, err := loadRun(s.Arg(1))
+	if err != nil {
+		return err
+	}
+	c := run.Compare(old)
+	c.Fprint(out)
+
+	if *fail && c.Significant && run.P50 > old.P50 {
+		return fmt.Errorf("latencies regressed: p50 %s > %s (p=%.4f)", round(run.P50), round(old.P50), c.P)
+	}
+	return nil
+}
+
+// loadRun loads a JSON summary or a results file.
+func loadRun(path string) (*hit.Result, error) {
+	if filepath.Ext(path) == ".json" {
+		return hit.LoadSummary(path)
+	}
+	return hit.LoadResults(path)
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"effective-go/hit-cli/hit"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ANSI escape codes of the dashboard.
+const (
+	ansiAltScreen  = "\x1b[?1049h\x1b[?25l" // switch to the alternate screen and hide the cursor
+	ansiMainScreen = "\x1b[?25h\x1b[?1049l" // show the cursor and switch back
+	ansiHome       = "\x1b[H\x1b[J"         // move the cursor home and clear the screen
+	ansiBold       = "\x1b[1m"
+	ansiRed        = "\x1b[31m"
+	ansiReset      = "\x1b[0m"
+)
+
+// sparkWidth is the number of snapshots that the sparklines show.
+const sparkWidth = 40
+
+// dashboard is a full-screen view of a run's progress that it redraws
+// with each snapshot.
+type dashboard struct {
+	out     io.Writer
+	target  string
+	workers int
+
+	rps, p50, p90, p99 []float64
+	statuses           map[int]int
+	errors             int
+	last               hit.Snapshot
+}
+
+func newDashboard(out io.Writer, target string, workers int) *dashboard {
+	return &dashboard{
+		out:      out,
+		target:   target,
+		workers:  workers,
+		statuses: map[int]int{},
+	}
+}
+
+// isTerminal reports whether w is a terminal.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	fi, err := f.Stat()
+	return err == nil && fi.Mode()&os.ModeCharDevice != 0
+}
+
+// start switches the terminal to the dashboard's screen.
+func (d *dashboard) start() { fmt.Fprint(d.out, ansiAltScreen) }
+
+// stop switches the terminal back to the screen before the dashboard.
+func (d *dashboard) stop() { fmt.Fprint(d.out, ansiMainScreen) }
+
+// update the dashboard with a snapshot and redraw it.
+func (d *dashboard) update(s hit.Snapshot) {
+	d.last = s
+	d.errors += s.Errors
+	for code, n := range s.Statuses {
+		d.statuses[code] += n
+	}
+	d.rps = push(d.rps, s.RPS)
+	d.p50 = push(d.p50, ms(s.P50))
+	d.p90 = push(d.p90, ms(s.P90))
+	d.p99 = push(d.p99, ms(s.P99))
+
+	fmt.Fprint(d.out, ansiHome+d.render())
+}
+
+// render the dashboard as text.
+func (d *dashboard) render() string {
+	var (
+		b strings.Builder
+		s = d.last
+	)
+	p := func(format string, args ...any) { fmt.Fprintf(&b, format, args...) }
+
+	p("%shit%s %s  %s elapsed\n\n", ansiBold, ansiReset, d.target, s.Elapsed.Round(100*time.Millisecond))
+	p("  %-8s %12.1f  %s\n", "RPS", s.RPS, sparkline(d.rps))
+	errRate := fmt.Sprintf("%11.1f%%", s.ErrorRate())
+	if s.Errors > 0 {
+		errRate = ansiRed + errRate + ansiReset
+	}
+	p("  %-8s %s  %d of %d requests\n", "Errors", errRate, d.errors, s.Total)
+	p("  %-8s %12d  of %d workers\n\n", "Active", s.Active, d.workers)
+
+	for _, l := range []struct {
+		name    string
+		d       time.Duration
+		history []float64
+	}{
+		{"p50", s.P50, d.p50},
+		{"p90", s.P90, d.p90},
+		{"p99", s.P99, d.p99},
+	} {
+		p("  %-8s %12s  %s\n", l.name, round(l.d), sparkline(l.history))
+	}
+
+	p("\n  %sStatus       Count%s\n", ansiBold, ansiReset)
+	codes := make([]int, 0, len(d.statuses))
+	for code := range d.statuses {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+	for _, code := range codes {
+		name := fmt.Sprint(code)
+		if code == 0 {
+			name = "error"
+		}
+		p("  %-8s %10d\n", name, d.statuses[code])
+	}
+	return b.String()
+}
+
+// push appends v to the last sparkWidth values.
+func push(values []float64, v float64) []float64 {
+	values = append(values, v)
+	if len(values) > sparkWidth {
+		values = values[len(values)-sparkWidth:]
+	}
+	return values
+}
+
+var sparks = []rune("▁▂▃▄▅▆▇█")
+
+// sparkline draws the values as bars scaled to the largest value.
+func sparkline(values []float64) string {
+	var top float64
+	for _, v := range values {
+		if v > top {
+			top = v
+		}
+	}
+	line := make([]rune, len(values))
+	for i, v := range values {
+		j := 0
+		if top > 0 {
+			j = int(v / top * float64(len(sparks)-1))
+		}
+		line[i] = sparks[j]
+	}
+	return string(line)
+}
+
+func ms(d time.Duration) float64 { return float64(d) / float64(time.Millisecond) }
@@ -0,0 +1,65 @@
+//go:build cli
+
+package main
+
+import (
+	"bytes"
+	"effective-go/hit-cli/hit"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSparkline(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		in   []float64
+		want string
+	}{
+		"empty": {nil, ""},
+		"zero":  {[]float64{0, 0}, "▁▁"},
+		"rise":  {[]float64{0, 1, 2, 7}, "▁▂▃█"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := sparkline(tt.in); got != tt.want {
+				t.Errorf("sparkline(%v)=%q; want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDashboard(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	if isTerminal(&out) {
+		t.Fatal("isTerminal(buffer)=true; want false")
+	}
+	d := newDashboard(&out, "http://foo", 4)
+	for i := 1; i <= sparkWidth+5; i++ {
+		d.update(hit.Snapshot{
+			Elapsed:  time.Duration(i) * time.Second,
+			Requests: 10, Errors: 1, Total: 10 * i, RPS: 10, Active: 3,
+			P50:      time.Millisecond,
+			Statuses: map[int]int{200: 9, 0: 1},
+		})
+	}
+	if got := len(d.rps); got != sparkWidth {
+		t.Errorf("rps history=%d; want %d", got, sparkWidth)
+	}
+
+	screen := d.render()
+	for _, want := range []string{
+		"http://foo", "45s elapsed", "10.0%", "45 of 450 requests", "of 4 workers",
+		"200             405", "error            45",
+	} {
+		if !strings.Contains(screen, want) {
+			t.Errorf("dashboard does not contain %q:\n%s", want, screen)
+		}
+	}
+}
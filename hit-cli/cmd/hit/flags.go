@@ -1,22 +1,53 @@
 package main
 
 import (
+	"crypto/tls"
+	"effective-go/hit-cli/hit"
 	"errors"
 	"flag"
 	"fmt"
+	"net/http"
 	"net/url"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type flags struct {
 	url       string
 	n, c, rps int
+	stages    hit.Stages
+	stageMode hit.StageMode
+	output    string
+	tls       hit.TLSOptions
+	proto     hit.Protocol
+	streams   int
+	feed      string
+	feedMode  hit.FeedMode
+	scenario  string
+	thres     thresholds
+	status    statuses
+	contains  string
+	retries   int
+	backoff   time.Duration
+	sessions  bool
+	user      string
+	bearer    string
+	oauth     hit.ClientCredentials
+	scopes    string
+	save      string
+	ui        bool
+	warmUp    warmUp
 }
 
 const usageText = `
 Usage:
   hit [options] url
+  hit [options] -scenario file
+  hit run plan-file
+  hit report [options] results-file
+  hit compare [options] old new
+  hit search [options] -slo slo -max load url
 Options:`
 
 // number is a natural number
@@ -45,6 +76,160 @@ func (n *number) String() string {
 	return strconv.Itoa(int(*n))
 }
 
+// stages is a load profile of comma separated duration:target pairs,
+// such as 30s:100,2m:500,30s:0.
+type stages hit.Stages
+
+// Set parses a load profile and sets the caller to its stages.
+func (s *stages) Set(v string) error {
+	var st stages
+	for _, p := range strings.Split(v, ",") {
+		d, t, ok := strings.Cut(p, ":")
+		if !ok {
+			return fmt.Errorf("%q: want duration:target", p)
+		}
+		duration, err := time.ParseDuration(d)
+		if err != nil || duration < 0 {
+			return fmt.Errorf("%q: invalid duration", d)
+		}
+		target, err := strconv.Atoi(t)
+		if err != nil || target < 0 {
+			return fmt.Errorf("%q: invalid target", t)
+		}
+		st = append(st, hit.Stage{Duration: duration, Target: target})
+	}
+	*s = st
+	return nil
+}
+
+func (s *stages) String() string {
+	p := make([]string, len(*s))
+	for i, st := range *s {
+		p[i] = fmt.Sprintf("%s:%d", st.Duration, st.Target)
+	}
+	return strings.Join(p, ",")
+}
+
+// stageMode is the name of a stage mode.
+type stageMode hit.StageMode
+
+// Set parses a stage mode name and sets the caller to the stage mode.
+func (m *stageMode) Set(s string) error {
+	v, err := hit.ParseStageMode(s)
+	*m = stageMode(v)
+	return err
+}
+
+func (m *stageMode) String() string { return hit.StageMode(*m).String() }
+
+// tlsVersion is a TLS version such as 1.2.
+type tlsVersion uint16
+
+// Set parses a TLS version and sets the caller to its crypto/tls constant.
+func (v *tlsVersion) Set(s string) error {
+	n, err := hit.ParseTLSVersion(s)
+	*v = tlsVersion(n)
+	return err
+}
+
+func (v *tlsVersion) String() string {
+	if *v == 0 {
+		return ""
+	}
+	return fmt.Sprintf("1.%d", *v-tls.VersionTLS10)
+}
+
+// protocol is an HTTP protocol name.
+type protocol hit.Protocol
+
+// Set parses a protocol name and sets the caller to the protocol.
+func (p *protocol) Set(s string) error {
+	v, err := hit.ParseProtocol(s)
+	*p = protocol(v)
+	return err
+}
+
+func (p *protocol) String() string { return string(*p) }
+
+// feedMode is the name of a feed mode.
+type feedMode hit.FeedMode
+
+// Set parses a feed mode name and sets the caller to the feed mode.
+func (m *feedMode) Set(s string) error {
+	v, err := hit.ParseFeedMode(s)
+	*m = feedMode(v)
+	return err
+}
+
+func (m *feedMode) String() string {
+	return [...]string{"sequential", "circular", "random"}[*m]
+}
+
+// thresholds are pass or fail thresholds.
+type thresholds []hit.Threshold
+
+// Set parses comma separated thresholds and adds them to the caller.
+func (t *thresholds) Set(s string) error {
+	for _, expr := range strings.Split(s, ",") {
+		th, err := hit.ParseThreshold(expr)
+		if err != nil {
+			return err
+		}
+		*t = append(*t, th)
+	}
+	return nil
+}
+
+func (t *thresholds) String() string {
+	p := make([]string, len(*t))
+	for i, th := range *t {
+		p[i] = th.String()
+	}
+	return strings.Join(p, ",")
+}
+
+// warmUp is a number of warm-up requests or a warm-up duration.
+type warmUp struct {
+	requests int
+	duration time.Duration
+}
+
+// Set parses a warm-up and sets the caller to it.
+func (w *warmUp) Set(s string) (err error) {
+	w.requests, w.duration, err = hit.ParseWarmUp(s)
+	return err
+}
+
+func (w *warmUp) String() string {
+	if w.duration > 0 {
+		return w.duration.String()
+	}
+	return strconv.Itoa(w.requests)
+}
+
+// statuses are HTTP status codes.
+type statuses []int
+
+// Set parses comma separated status codes and adds them to the caller.
+func (st *statuses) Set(s string) error {
+	for _, v := range strings.Split(s, ",") {
+		code, err := strconv.Atoi(v)
+		if err != nil || code < 100 || code > 599 {
+			return fmt.Errorf("%q: invalid status code", v)
+		}
+		*st = append(*st, code)
+	}
+	return nil
+}
+
+func (st *statuses) String() string {
+	p := make([]string, len(*st))
+	for i, code := range *st {
+		p[i] = strconv.Itoa(code)
+	}
+	return strings.Join(p, ",")
+}
+
 func (f *flags) parse(s *flag.FlagSet, args []string) (err error) {
 	flag.Usage = func() {
 		fmt.Fprintln(s.Output(), usageText[1:])
@@ -54,12 +239,53 @@ func (f *flags) parse(s *flag.FlagSet, args []string) (err error) {
 	s.Var(toNumber(&f.n), "n", "Number of requests to make")
 	s.Var(toNumber(&f.c), "c", "Concurrency level")
 	s.Var(toNumber(&f.rps), "t", "Throttle requests per second")
+	s.Var((*stages)(&f.stages), "stages",
+		"Load profile of duration:target steps (e.g. 30s:100,2m:500,30s:0)")
+	s.Var((*stageMode)(&f.stageMode), "stage-mode",
+		"What the -stages targets are: rps, or concurrency for concurrent workers")
+	s.StringVar(&f.output, "o", "text", "Output format: text, json, csv, html or ndjson")
+	s.StringVar(&f.tls.CAFile, "cacert", "", "PEM bundle of CAs to trust")
+	s.StringVar(&f.tls.CertFile, "cert", "", "PEM client certificate for mTLS")
+	s.StringVar(&f.tls.KeyFile, "key", "", "PEM key of the client certificate")
+	s.BoolVar(&f.tls.Insecure, "insecure", false, "Skip verifying the server certificate")
+	s.Var((*tlsVersion)(&f.tls.MinVersion), "tls-min", "Minimum TLS version: 1.0, 1.1, 1.2 or 1.3")
+	s.StringVar(&f.tls.ServerName, "sni", "", "Server name to send and verify instead of the URL's host")
+	s.Var((*protocol)(&f.proto), "proto", "Protocol: http1, h2 (HTTP/2 over TLS) or h2c (cleartext HTTP/2)")
+	s.Var(toNumber(&f.streams), "max-streams", "Maximum HTTP/2 streams per connection")
+	s.StringVar(&f.feed, "feed", "", "CSV or JSONL file of rows to fill in the url's {{field}} placeholders")
+	s.Var((*feedMode)(&f.feedMode), "feed-mode", "Order to feed the rows in: sequential, circular or random")
+	s.StringVar(&f.scenario, "scenario", "", "JSON file of a scenario to run instead of requesting a url")
+	s.Var(&f.status, "expect-status", "Comma separated status codes to check the responses for")
+	s.StringVar(&f.contains, "expect-body", "", "Text to check the response bodies for")
+	s.Var(toNumber(&f.retries), "retries", "Retry failed requests up to this many times")
+	s.DurationVar(&f.backoff, "retry-backoff", 100*time.Millisecond, "Delay before the first retry, doubling with each retry")
+	s.BoolVar(&f.sessions, "sessions", false, "Keep cookies per concurrent worker, such as to stay logged in across scenario steps")
+	s.StringVar(&f.user, "user", "", "Basic authentication credentials as user:password")
+	s.StringVar(&f.bearer, "bearer", "", "Bearer token to authorize the requests with")
+	s.StringVar(&f.oauth.TokenURL, "oauth-token-url", "", "OAuth2 token endpoint to fetch client credentials tokens from")
+	s.StringVar(&f.oauth.ClientID, "oauth-client-id", "", "OAuth2 client ID")
+	s.StringVar(&f.oauth.ClientSecret, "oauth-client-secret", "", "OAuth2 client secret")
+	s.StringVar(&f.scopes, "oauth-scopes", "", "Comma separated OAuth2 scopes to request")
+	s.BoolVar(&f.ui, "ui", false, "Show a live dashboard instead of progress lines when the output is a terminal")
+	s.StringVar(&f.save, "save", "", "File to save each request's result to, to report it again with hit report")
+	s.Var(&f.warmUp, "warmup", "Warm-up requests (e.g. 100) or duration (e.g. 10s) to exclude from the statistics")
+	s.Var(&f.thres, "threshold", "Pass or fail threshold, repeatable or comma separated (e.g. p99<200ms,errors<1%,rps>=500)")
 
 	if err := s.Parse(args); err != nil {
 		return err
 	}
 	f.url = s.Arg(0)
+	if len(f.stages) > 0 && !isSet(s, "n") {
+		f.n = 0 // run until the last stage ends
+	}
+	if f.stageMode == hit.StageConcurrency && !isSet(s, "c") {
+		f.c = f.stages.Max() // the stages set the workers
+	}
 
+	if err := f.interpolate(); err != nil {
+		fmt.Fprintln(s.Output(), err)
+		return err
+	}
 	if err := f.validate(); err != nil {
 		fmt.Fprintln(s.Output(), err)
 		s.Usage()
@@ -68,14 +294,168 @@ func (f *flags) parse(s *flag.FlagSet, args []string) (err error) {
 	return nil
 }
 
+// interpolate the ${NAME} and ${file:/path} references of the
+// credentials. The url keeps its references to print it: the requests
+// interpolate them.
+func (f *flags) interpolate() error {
+	for _, v := range []struct {
+		flag  string
+		value *string
+	}{
+		{"user", &f.user},
+		{"bearer", &f.bearer},
+		{"oauth-token-url", &f.oauth.TokenURL},
+		{"oauth-client-id", &f.oauth.ClientID},
+		{"oauth-client-secret", &f.oauth.ClientSecret},
+	} {
+		var err error
+		if *v.value, err = hit.Interpolate(*v.value); err != nil {
+			return fmt.Errorf("-%s: %w", v.flag, err)
+		}
+	}
+	return nil
+}
+
+// checks returns the response checks of the flags.
+func (f *flags) checks() []hit.Check {
+	var checks []hit.Check
+	if len(f.status) > 0 {
+		checks = append(checks, hit.StatusIn(f.status...))
+	}
+	if f.contains != "" {
+		checks = append(checks, hit.BodyContains(f.contains))
+	}
+	return checks
+}
+
+// retry returns the retry policy of the flags, if any.
+func (f *flags) retry() *hit.RetryPolicy {
+	if f.retries == 0 {
+		return nil
+	}
+	return &hit.RetryPolicy{
+		MaxAttempts: f.retries + 1,
+		Backoff:     f.backoff,
+		MaxBackoff:  10 * f.backoff,
+		Jitter:      true,
+		RetryAfter:  true,
+	}
+}
+
+// isSet reports whether the flag with the name was set on the command line.
+func isSet(s *flag.FlagSet, name string) (set bool) {
+	s.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
 func (f *flags) validate() error {
-	if f.c > f.n {
+	if f.n > 0 && f.c > f.n {
 		return fmt.Errorf("-c=%d: must be less than or equal to -n=%d", f.c, f.n)
 	}
+	if f.n > 0 && f.warmUp.requests >= f.n {
+		return fmt.Errorf("-warmup=%d: must be less than -n=%d", f.warmUp.requests, f.n)
+	}
+	if f.stageMode == hit.StageConcurrency {
+		if len(f.stages) == 0 {
+			return errors.New("-stage-mode=concurrency: requires -stages")
+		}
+		if f.c != f.stages.Max() {
+			return errors.New("-c: cannot be used with -stage-mode=concurrency")
+		}
+	}
+	if len(f.stages) > 0 && f.rps > 0 {
+		return errors.New("-t: cannot be used with -stages")
+	}
+	if err := hit.ValidOutput(f.output); err != nil {
+		return fmt.Errorf("-o: %w", err)
+	}
+	if (f.tls.CertFile == "") != (f.tls.KeyFile == "") {
+		return errors.New("-cert and -key should be set together")
+	}
+	if f.streams > 0 && f.proto != hit.HTTP2 && f.proto != hit.H2C {
+		return errors.New("-max-streams: requires -proto=h2 or -proto=h2c")
+	}
+	if err := f.validateAuth(); err != nil {
+		return err
+	}
 
-	if err := validateURL(f.url); err != nil {
+	if f.scenario != "" {
+		if f.url != "" {
+			return errors.New("url: cannot be used with -scenario")
+		}
+		return nil
+	}
+	u, err := hit.Interpolate(f.url)
+	if err != nil {
+		return fmt.Errorf("url: %w", err)
+	}
+	if err := validateURL(u); err != nil {
 		return fmt.Errorf("url: %w", err)
 	}
+	if err := validateProtocol(f.proto, u); err != nil {
+		return fmt.Errorf("-proto=%s: %w", f.proto, err)
+	}
+	return nil
+}
+
+// auth returns the authentication of the flags, if any. The tokens are
+// fetched with the TLS configuration of the requests.
+func (f *flags) auth(tlsConfig *tls.Config) hit.Auth {
+	switch {
+	case f.user != "":
+		user, password, _ := strings.Cut(f.user, ":")
+		return hit.BasicAuth(user, password)
+	case f.bearer != "":
+		return hit.BearerToken(f.bearer)
+	case f.oauth.TokenURL != "":
+		if f.scopes != "" {
+			f.oauth.Scopes = strings.Split(f.scopes, ",")
+		}
+		f.oauth.Client = &http.Client{
+			Timeout:   10 * time.Second,
+			Transport: &http.Transport{TLSClientConfig: tlsConfig},
+		}
+		return &f.oauth
+	}
+	return nil
+}
+
+// validateAuth checks that the flags set at most one authentication.
+func (f *flags) validateAuth() error {
+	var n int
+	for _, v := range []string{f.user, f.bearer, f.oauth.TokenURL} {
+		if v != "" {
+			n++
+		}
+	}
+	if n > 1 {
+		return errors.New("-user, -bearer and -oauth-token-url: cannot be used together")
+	}
+	if f.oauth.TokenURL == "" {
+		return nil
+	}
+	if f.oauth.ClientID == "" {
+		return errors.New("-oauth-token-url: requires -oauth-client-id")
+	}
+	if err := validateURL(f.oauth.TokenURL); err != nil {
+		return fmt.Errorf("-oauth-token-url: %w", err)
+	}
+	return nil
+}
+
+// validateProtocol checks that the URL's scheme can carry the protocol.
+func validateProtocol(p hit.Protocol, s string) error {
+	u, _ := url.Parse(s)
+	switch {
+	case p == hit.HTTP2 && u.Scheme != "https":
+		return errors.New("requires an https url")
+	case p == hit.H2C && u.Scheme != "http":
+		return errors.New("requires an http url")
+	}
 	return nil
 }
 
@@ -86,8 +466,8 @@ func validateURL(s string) error {
 		err = errors.New("required")
 	case err != nil:
 		err = errors.New("parse error")
-	case u.Scheme != "http":
-		err = errors.New("only supported scheme is http")
+	case u.Scheme != "http" && u.Scheme != "https":
+		err = errors.New("only supported schemes are http and https")
 	case u.Host == "":
 		err = errors.New("missing host")
 	}
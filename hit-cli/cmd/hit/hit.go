@@ -11,6 +11,7 @@ import (
 	"os"
 	"os/signal"
 	"runtime"
+	"strings"
 	"time"
 )
 
@@ -26,12 +27,25 @@ func banner() string { return bannerText[1:] }
 
 func main() {
 	if err := run(flag.CommandLine, os.Args[1:], os.Stdout); err != nil {
-		fmt.Fprintln(os.Stderr, "error occurred:", err)
+		fmt.Fprintln(os.Stderr, "error occurred:", hit.Redact(err.Error()))
 		os.Exit(1)
 	}
 }
 
 func run(s *flag.FlagSet, args []string, out io.Writer) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "report":
+			return runReport(s, args[1:], out)
+		case "compare":
+			return runCompare(s, args[1:], out)
+		case "run":
+			return runPlan(s, args[1:], out)
+		case "search":
+			return runSearch(s, args[1:], out)
+		}
+	}
+
 	f := &flags{
 		n: 100,
 		c: runtime.NumCPU(),
@@ -40,14 +54,13 @@ func run(s *flag.FlagSet, args []string, out io.Writer) error {
 		return err
 	}
 
-	fmt.Fprintln(out, banner())
-	fmt.Fprintf(out, "Making %d requests to %s with a concurrency level of %d.\n",
-		f.n, f.url, f.c)
-	if f.rps > 0 {
-		fmt.Fprintf(out, "(RPS: %d)\n", f.rps)
+	// only the text format is for humans
+	text := f.output == "text"
+	if text {
+		printHeader(out, f)
 	}
 
-	request, err := http.NewRequest(http.MethodGet, f.url, http.NoBody)
+	tlsConfig, err := f.tls.Config()
 	if err != nil {
 		return err
 	}
@@ -56,20 +69,179 @@ func run(s *flag.FlagSet, args []string, out io.Writer) error {
 		C:       f.c,
 		RPS:     f.rps,
 		Timeout: 10 * time.Second,
+		Stages:  f.stages,
+		TLS:     tlsConfig,
+
+		StageMode:  f.stageMode,
+		Protocol:   f.proto,
+		MaxStreams: f.streams,
+		Thresholds: f.thres,
+		Checks:     f.checks(),
+		Retry:      f.retry(),
+		Sessions:   f.sessions,
+		Auth:       f.auth(tlsConfig),
+
+		WarmUp:         f.warmUp.requests,
+		WarmUpDuration: f.warmUp.duration,
+	}
+	var ui *dashboard
+	switch {
+	case text && f.ui && isTerminal(out):
+		ui = newDashboard(out, target(f), f.c)
+		c.OnInterval = ui.update
+	case text:
+		c.OnInterval = progress(out)
+	}
+	var report hit.Reporter
+	if f.output == hit.NDJSONOutput {
+		c.OnResult = hit.NDJSON(out)
+	} else if report, err = hit.Format(f.output); err != nil {
+		return err
+	}
+	var saved *results
+	if f.save != "" {
+		if saved, err = createResults(f.save); err != nil {
+			return err
+		}
+		c.OnResult = saved.record(c.OnResult)
 	}
 
-	const timeout = time.Second
+	timeout := time.Second
+	if len(c.Stages) > 0 {
+		// leave time for the last requests to finish
+		timeout = c.Stages.Duration() + c.Timeout
+	}
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
 	defer cancel()
 	defer stop()
 
-	sum := c.Do(ctx, request, f.n)
-	sum.Fprint(out)
+	sum, err := watch(ctx, c, f, ui)
+	if saved != nil {
+		if cerr := saved.Close(); err == nil {
+			err = cerr
+		}
+	}
+	if err != nil {
+		return err
+	}
+	if report != nil {
+		if err := report.Report(out, sum); err != nil {
+			return err
+		}
+	}
 
 	if err := ctx.Err(); errors.Is(err, context.DeadlineExceeded) {
 		return fmt.Errorf("timed out in %s", timeout)
 	}
+	if failed := sum.Failed(); len(failed) > 0 {
+		return thresholdsError(failed)
+	}
 
 	return nil
 }
+
+// thresholdsError returns an error that lists the failed thresholds.
+func thresholdsError(failed []hit.ThresholdResult) error {
+	p := make([]string, len(failed))
+	for i, t := range failed {
+		p[i] = t.String()
+	}
+	return fmt.Errorf("%d threshold(s) failed: %s", len(failed), strings.Join(p, ", "))
+}
+
+// watch sends the requests while the dashboard, if any, is on the
+// screen. It restores the screen before the summary is printed, even
+// if sending panics.
+func watch(ctx context.Context, c *hit.Client, f *flags, ui *dashboard) (*hit.Result, error) {
+	if ui != nil {
+		ui.start()
+		defer ui.stop()
+	}
+	return send(ctx, c, f)
+}
+
+// send runs the scenario, or sends the requests to the url, with the
+// client and returns the aggregated result.
+func send(ctx context.Context, c *hit.Client, f *flags) (*hit.Result, error) {
+	var feeder *hit.Feeder
+	if f.feed != "" {
+		rows, err := hit.LoadFeed(f.feed)
+		if err != nil {
+			return nil, err
+		}
+		feeder = &hit.Feeder{Rows: rows, Mode: f.feedMode}
+	}
+
+	if f.scenario != "" {
+		sc, err := hit.LoadScenario(f.scenario)
+		if err != nil {
+			return nil, err
+		}
+		sc.Feeder = feeder
+		return c.RunScenario(ctx, sc, f.n)
+	}
+
+	if feeder != nil {
+		src, err := hit.Feed(feeder, hit.Template{URL: f.url})
+		if err != nil {
+			return nil, err
+		}
+		return c.Run(ctx, src, f.n), nil
+	}
+	u, err := hit.Interpolate(f.url)
+	if err != nil {
+		return nil, err
+	}
+	request, err := http.NewRequest(http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(ctx, request, f.n), nil
+}
+
+// printHeader prints the banner and the run's settings.
+func printHeader(out io.Writer, f *flags) {
+	fmt.Fprintln(out, banner())
+	target := target(f)
+	switch {
+	case len(f.stages) > 0 && f.stageMode == hit.StageConcurrency:
+		fmt.Fprintf(out, "Running a %s concurrency profile to %s with up to %d workers.\n",
+			f.stages.Duration(), target, f.c)
+	case len(f.stages) > 0:
+		fmt.Fprintf(out, "Running a %s load profile to %s with a concurrency level of %d.\n",
+			f.stages.Duration(), target, f.c)
+	default:
+		fmt.Fprintf(out, "Making %d requests to %s with a concurrency level of %d.\n",
+			f.n, target, f.c)
+	}
+	if f.rps > 0 {
+		fmt.Fprintf(out, "(RPS: %d)\n", f.rps)
+	}
+	switch {
+	case f.warmUp.duration > 0:
+		fmt.Fprintf(out, "(Warm-up: %s)\n", f.warmUp.duration)
+	case f.warmUp.requests > 0:
+		fmt.Fprintf(out, "(Warm-up: %d requests)\n", f.warmUp.requests)
+	}
+}
+
+// target describes what the run sends requests to.
+func target(f *flags) string {
+	if f.scenario != "" {
+		return "the scenario in " + f.scenario
+	}
+	return f.url
+}
+
+// progress returns a function that prints a line of progress for each
+// snapshot of a run.
+func progress(out io.Writer) func(hit.Snapshot) {
+	return func(s hit.Snapshot) {
+		fmt.Fprintf(out, "[%6s] %6d requests  %8.1f rps  %5.1f%% errors  p50 %-8s p90 %-8s p99 %s\n",
+			s.Elapsed.Round(100*time.Millisecond), s.Total, s.RPS, s.ErrorRate(),
+			round(s.P50), round(s.P90), round(s.P99))
+	}
+}
+
+func round(d time.Duration) time.Duration { return d.Round(time.Microsecond) }
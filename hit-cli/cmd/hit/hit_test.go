@@ -5,6 +5,8 @@ package main
 import (
 	"bytes"
 	"flag"
+	"os"
+	"path/filepath"
 	"runtime"
 	"strconv"
 	"strings"
@@ -35,19 +37,60 @@ func TestRun(t *testing.T) {
 			"-n=20 -c=5 http://foo",
 			"20 requests to http://foo with a concurrency level of 5",
 		},
+		"https": {
+			"-n=1 -c=1 https://foo",
+			"1 requests to https://foo with a concurrency level of 1",
+		},
+		"stages": {
+			"-c=1 -stages=100ms:10 http://foo",
+			"100ms load profile to http://foo with a concurrency level of 1",
+		},
 	}
 	sad := map[string]string{
-		"url/missing": "",
-		"url/err":     "://foo",
-		"url/host":    "http://",
-		"url/scheme":  "ftp://",
-		"c/err":       "-c=x http://foo",
-		"n/err":       "-n=x http://foo",
-		"c/neg":       "-c=-1 http://foo",
-		"n/neg":       "-n=-1 http://foo",
-		"c/zero":      "-c=0 http://foo",
-		"n/zero":      "-n=0 http://foo",
-		"c/greater":   "-n=1 -c=2 http://foo",
+		"url/missing":       "",
+		"url/err":           "://foo",
+		"url/host":          "http://",
+		"url/scheme":        "ftp://",
+		"c/err":             "-c=x http://foo",
+		"n/err":             "-n=x http://foo",
+		"c/neg":             "-c=-1 http://foo",
+		"n/neg":             "-n=-1 http://foo",
+		"c/zero":            "-c=0 http://foo",
+		"n/zero":            "-n=0 http://foo",
+		"c/greater":         "-n=1 -c=2 http://foo",
+		"stages/err":        "-stages=1s http://foo",
+		"stages/dur":        "-stages=x:1 http://foo",
+		"stages/rps":        "-stages=1s:-1 http://foo",
+		"stages/t":          "-stages=1s:1 -t=1 http://foo",
+		"o/err":             "-o=xml http://foo",
+		"tls/min":           "-tls-min=2 https://foo",
+		"tls/key":           "-cert=cert.pem https://foo",
+		"proto/err":         "-proto=h3 https://foo",
+		"proto/h2":          "-proto=h2 http://foo",
+		"proto/h2c":         "-proto=h2c https://foo",
+		"streams/h1":        "-max-streams=2 http://foo",
+		"feed/mode":         "-feed=keys.csv -feed-mode=x http://foo",
+		"scenario/url":      "-scenario=s.json http://foo",
+		"threshold/metric":  "-threshold=p99<1s,foo<1 http://foo",
+		"threshold/value":   "-threshold=p99<fast http://foo",
+		"expect/status":     "-expect-status=200,abc http://foo",
+		"retries/neg":       "-retries=-1 http://foo",
+		"auth/both":         "-user=a:b -bearer=t http://foo",
+		"oauth/id":          "-oauth-token-url=http://foo/token http://foo",
+		"oauth/url":         "-oauth-token-url=ftp://foo -oauth-client-id=hit http://foo",
+		"report/file":       "report",
+		"report/o":          "report -o=ndjson results.ndjson",
+		"compare/args":      "compare old.json",
+		"run/plan":          "run",
+		"stage-mode/stages": "-stage-mode=concurrency http://foo",
+		"stage-mode/c":      "-stage-mode=concurrency -stages=1s:2 -c=4 http://foo",
+		"warmup/n":          "-n=10 -warmup=10 http://foo",
+		"warmup/value":      "-warmup=soon http://foo",
+		"search/slo":        "search -max=10 http://foo",
+		"search/max":        "search -slo=p99<1s http://foo",
+		"search/mode":       "search -mode=qps -slo=p99<1s -max=10 http://foo",
+		"url/env":           "http://foo/${HIT_UNSET_VARIABLE}",
+		"bearer/file":       "-bearer=${file:/nonexistent/token} http://foo",
 	}
 	for name, tt := range happy {
 		tt := tt
@@ -78,3 +121,28 @@ func TestRun(t *testing.T) {
 		})
 	}
 }
+
+func TestRunReport(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "results.ndjson")
+	results := `{"time":"2024-01-02T03:04:05Z","duration_ms":10,"status":200,"bytes":1,"reused":false}
+{"time":"2024-01-02T03:04:05.5Z","duration_ms":500,"status":500,"bytes":1,"reused":false}
+`
+	if err := os.WriteFile(path, []byte(results), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	e := &testEnv{args: "report -o=csv " + path}
+	if err := e.run(); err != nil {
+		t.Fatalf("\ngot %q;\nwant nil err", err)
+	}
+	if want := "all,2,1,50,2,2,1000,10,500"; !strings.Contains(e.stdout.String(), want) {
+		t.Errorf("\ngot:\n%s\nwant %q", e.stdout.String(), want)
+	}
+
+	e = &testEnv{args: "report -threshold=errors<1% " + path}
+	if err := e.run(); err == nil {
+		t.Error("got nil; want a failed threshold err")
+	}
+}
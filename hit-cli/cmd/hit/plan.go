@@ -0,0 +1,143 @@
+package main
+
+import (
+	"bufio"
+	"context"
+	"effective-go/hit-cli/hit"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+)
+
+const planUsageText = `
+Usage:
+  hit run plan-file
+
+Runs the load test that a JSON plan file describes.`
+
+// runPlan runs a plan file and reports its result to the plan's
+// outputs, or as text if it has none.
+func runPlan(s *flag.FlagSet, args []string, out io.Writer) error {
+	s.Usage = func() {
+		fmt.Fprintln(s.Output(), planUsageText[1:])
+		s.PrintDefaults()
+	}
+	if err := s.Parse(args); err != nil {
+		return err
+	}
+	if s.NArg() != 1 {
+		err := errors.New("requires a plan file")
+		fmt.Fprintln(s.Output(), err)
+		s.Usage()
+		return err
+	}
+	path := s.Arg(0)
+	p, err := hit.LoadPlan(path)
+	if err != nil {
+		return err
+	}
+	if len(p.Outputs) == 0 {
+		p.Outputs = []hit.Output{{Format: "text"}}
+	}
+
+	var (
+		reports []func(*hit.Result) error
+		saved   []*results
+		files   []*os.File
+	)
+	defer func() {
+		for _, r := range saved {
+			_ = r.Close()
+		}
+		for _, f := range files {
+			_ = f.Close()
+		}
+	}()
+	for _, o := range p.Outputs {
+		if o.Format == hit.NDJSONOutput {
+			r := &results{w: bufio.NewWriter(out)}
+			if o.Path != "" {
+				if r, err = createResults(o.Path); err != nil {
+					return err
+				}
+			}
+			saved = append(saved, r)
+			p.Client.OnResult = r.record(p.Client.OnResult)
+			continue
+		}
+
+		w := out
+		if o.Path != "" {
+			f, err := os.Create(o.Path)
+			if err != nil {
+				return err
+			}
+			files = append(files, f)
+			w = f
+		} else if o.Format == "text" {
+			printPlanHeader(out, path, p)
+			p.Client.OnInterval = progress(out)
+		}
+		report, _ := hit.Format(o.Format) // LoadPlan checked the format
+		reports = append(reports, func(sum *hit.Result) error {
+			return report.Report(w, sum)
+		})
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	sum, err := p.Run(ctx)
+	for _, r := range saved {
+		if cerr := r.Close(); err == nil {
+			err = cerr
+		}
+	}
+	saved = nil
+	if err != nil {
+		return err
+	}
+	for _, report := range reports {
+		if rerr := report(sum); err == nil {
+			err = rerr
+		}
+	}
+	for _, f := range files {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+	}
+	files = nil
+	if err != nil {
+		return err
+	}
+	if failed := sum.Failed(); len(failed) > 0 {
+		return thresholdsError(failed)
+	}
+	return nil
+}
+
+// printPlanHeader prints the banner and the plan's settings.
+func printPlanHeader(out io.Writer, path string, p *hit.Plan) {
+	fmt.Fprintln(out, banner())
+	name := p.Name
+	if name == "" {
+		name = path
+	}
+	switch {
+	case len(p.Client.Stages) > 0 && p.Client.StageMode == hit.StageConcurrency:
+		fmt.Fprintf(out, "Running the plan %s: a %s concurrency profile with up to %d workers.\n",
+			name, p.Client.Stages.Duration(), p.Client.C)
+	case len(p.Client.Stages) > 0:
+		fmt.Fprintf(out, "Running the plan %s: a %s load profile with a concurrency level of %d.\n",
+			name, p.Client.Stages.Duration(), p.Client.C)
+	case p.Requests == 0:
+		fmt.Fprintf(out, "Running the plan %s: requests for %s with a concurrency level of %d.\n",
+			name, p.Duration, p.Client.C)
+	default:
+		fmt.Fprintf(out, "Running the plan %s: %d requests with a concurrency level of %d.\n",
+			name, p.Requests, p.Client.C)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bufio"
+	"effective-go/hit-cli/hit"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+const reportUsageText = `
+Usage:
+  hit report [options] results-file
+
+Reports the results that a run saved with -save.
+Options:`
+
+// runReport reports the results file of a previous run again.
+func runReport(s *flag.FlagSet, args []string, out io.Writer) error {
+	var (
+		output string
+		thres  thresholds
+	)
+	s.Usage = func() {
+		fmt.Fprintln(s.Output(), reportUsageText[1:])
+		s.PrintDefaults()
+	}
+	s.StringVar(&output, "o", "text", "Output format: text, json, csv or html")
+	s.Var(&thres, "threshold", "Pass or fail threshold, repeatable or comma separated (e.g. p99<200ms,errors<1%,rps>=500)")
+	if err := s.Parse(args); err != nil {
+		return err
+	}
+
+	report, err := hit.Format(output)
+	if err != nil {
+		err = fmt.Errorf("-o: %w", err)
+	} else if s.NArg() != 1 {
+		err = errors.New("requires a results file")
+	}
+	if err != nil {
+		fmt.Fprintln(s.Output(), err)
+		s.Usage()
+		return err
+	}
+
+	sum, err := hit.LoadResults(s.Arg(0))
+	if err != nil {
+		return err
+	}
+	for _, th := range thres {
+		sum.Thresholds = append(sum.Thresholds, th.Eval(sum))
+	}
+	if err := report.Report(out, sum); err != nil {
+		return err
+	}
+	if failed := sum.Failed(); len(failed) > 0 {
+		return thresholdsError(failed)
+	}
+	return nil
+}
+
+// results is a file, or a writer, that a run saves each request's
+// result to.
+type results struct {
+	w *bufio.Writer
+	c io.Closer // c closes the file, if any
+}
+
+func createResults(path string) (*results, error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	return &results{w: bufio.NewWriter(f), c: f}, nil
+}
+
+// record returns a function that saves each result after calling next,
+// if any.
+func (r *results) record(next func(*hit.Result)) func(*hit.Result) {
+	save := hit.NDJSON(r.w)
+	return func(result *hit.Result) {
+		if next != nil {
+			next(result)
+		}
+		save(result)
+	}
+}
+
+// Close flushes the saved results and closes the file, if any.
+func (r *results) Close() error {
+	err := r.w.Flush()
+	if r.c == nil {
+		return err
+	}
+	if cerr := r.c.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"context"
+	"effective-go/hit-cli/hit"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/signal"
+	"runtime"
+	"time"
+)
+
+const searchUsageText = `
+Usage:
+  hit search [options] -slo slo -max load url
+
+Finds the highest request rate, or concurrency level, that the url
+sustains under the SLO. It runs steps at increasing loads, doubling
+from -min until a step violates the SLO, and then bisects.
+Options:`
+
+// runSearch searches the highest load that a url sustains under an SLO.
+func runSearch(s *flag.FlagSet, args []string, out io.Writer) error {
+	var (
+		mode     = "rps"
+		slo      thresholds
+		min, max = 1, 0
+		c        = runtime.NumCPU()
+		duration = 10 * time.Second
+	)
+	s.Usage = func() {
+		fmt.Fprintln(s.Output(), searchUsageText[1:])
+		s.PrintDefaults()
+	}
+	s.StringVar(&mode, "mode", mode, "Load to search: rps or concurrency")
+	s.Var(&slo, "slo", "Thresholds that each step must pass, repeatable or comma separated (e.g. p99<200ms,errors<1%)")
+	s.Var(toNumber(&min), "min", "Load of the first step")
+	s.Var(toNumber(&max), "max", "Highest load to try")
+	s.Var(toNumber(&c), "c", "Least concurrency level of the rps mode, which uses a worker per rps at least")
+	s.DurationVar(&duration, "d", duration, "Duration of each step")
+	if err := s.Parse(args); err != nil {
+		return err
+	}
+
+	m, err := hit.ParseSearchMode(mode)
+	u, uerr := hit.Interpolate(s.Arg(0))
+	if uerr == nil {
+		uerr = validateURL(u)
+	}
+	switch {
+	case err != nil:
+		err = fmt.Errorf("-mode: %w", err)
+	case len(slo) == 0:
+		err = errors.New("-slo: required")
+	case max == 0:
+		err = errors.New("-max: required")
+	case max < min:
+		err = fmt.Errorf("-max=%d: must be greater than or equal to -min=%d", max, min)
+	case duration <= 0:
+		err = errors.New("-d: should be positive")
+	case uerr != nil:
+		err = fmt.Errorf("url: %w", uerr)
+	}
+	if err != nil {
+		fmt.Fprintln(s.Output(), err)
+		s.Usage()
+		return err
+	}
+	request, err := http.NewRequest(http.MethodGet, u, http.NoBody)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(out, banner())
+	fmt.Fprintf(out, "Searching the highest %s from %d to %d that %s sustains under %s, in steps of %s.\n\n",
+		m, min, max, s.Arg(0), slo.String(), duration)
+	search := &hit.Search{
+		Client:   &hit.Client{C: c, Timeout: 10 * time.Second},
+		Mode:     m,
+		SLO:      slo,
+		Min:      min,
+		Max:      max,
+		Duration: duration,
+		OnStep: func(st hit.SearchStep) {
+			status := "pass"
+			if !st.Pass {
+				status = "fail"
+			}
+			fmt.Fprintf(out, "%s %-6d %s  %8.1f rps  p99 %s\n",
+				m, st.Load, status, st.Result.RPS, round(st.Result.P99))
+		},
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	sr, err := search.Run(ctx, hit.Clone(request))
+	if sr != nil {
+		sr.Fprint(out)
+	}
+	if err != nil {
+		return err
+	}
+	if sr.Result == nil {
+		return fmt.Errorf("no %s from %d passed the SLO", m, min)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package hit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Auth authenticates requests. The Client authorizes each request
+// before it starts measuring the request's performance.
+type Auth interface {
+	// Authorize sets the credentials of a request.
+	Authorize(r *http.Request) error
+}
+
+// AuthFunc is an adapter to allow an ordinary function to be an Auth.
+type AuthFunc func(r *http.Request) error
+
+// Authorize calls f(r).
+func (f AuthFunc) Authorize(r *http.Request) error { return f(r) }
+
+// Authenticate changes the Client's authentication.
+func Authenticate(a Auth) Option {
+	return func(c *Client) { c.Auth = a }
+}
+
+// BasicAuth authorizes requests with HTTP basic authentication.
+func BasicAuth(user, password string) Auth {
+	return AuthFunc(func(r *http.Request) error {
+		r.SetBasicAuth(user, password)
+		return nil
+	})
+}
+
+// BearerToken authorizes requests with a static bearer token.
+func BearerToken(token string) Auth {
+	return AuthFunc(func(r *http.Request) error {
+		r.Header.Set("Authorization", "Bearer "+token)
+		return nil
+	})
+}
+
+// expiryDelta refreshes tokens before they expire so that a request
+// does not reach the server with an expired token.
+const expiryDelta = 10 * time.Second
+
+// ClientCredentials authorizes requests with bearer tokens fetched with
+// the OAuth2 client credentials grant. It fetches a token on the first
+// request and again when the token expires. Its workers share the
+// token: one fetches it while the others wait.
+type ClientCredentials struct {
+	TokenURL     string
+	ClientID     string
+	ClientSecret string
+	Scopes       []string
+
+	// Client fetches the tokens. It defaults to http.DefaultClient.
+	Client *http.Client
+
+	mu     sync.Mutex
+	token  string
+	expiry time.Time // zero if the token does not expire
+}
+
+// Authorize sets the request's bearer token, fetching a new token if
+// there is none or it is about to expire.
+func (cc *ClientCredentials) Authorize(r *http.Request) error {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	if cc.token == "" || (!cc.expiry.IsZero() && time.Now().Add(expiryDelta).After(cc.expiry)) {
+		if err := cc.fetch(r); err != nil {
+			return err
+		}
+	}
+	r.Header.Set("Authorization", "Bearer "+cc.token)
+	return nil
+}
+
+// fetch a token for the request from the token endpoint.
+func (cc *ClientCredentials) fetch(r *http.Request) error {
+	form := url.Values{"grant_type": {"client_credentials"}}
+	if len(cc.Scopes) > 0 {
+		form.Set("scope", strings.Join(cc.Scopes, " "))
+	}
+	tr, err := http.NewRequestWithContext(r.Context(), http.MethodPost, cc.TokenURL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("token: %w", err)
+	}
+	tr.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	tr.SetBasicAuth(url.QueryEscape(cc.ClientID), url.QueryEscape(cc.ClientSecret))
+
+	client := cc.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	response, err := client.Do(tr)
+	if err != nil {
+		return fmt.Errorf("token: %w", err)
+	}
+	defer response.Body.Close()
+	body, err := io.ReadAll(io.LimitReader(response.Body, 1<<20))
+	if err != nil {
+		return fmt.Errorf("token: %w", err)
+	}
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("token: status %d: %s", response.StatusCode, strings.TrimSpace(string(body)))
+	}
+
+	var token struct {
+		AccessToken string `json:"access_token"`
+		ExpiresIn   int64  `json:"expires_in"`
+	}
+	if err := json.Unmarshal(body, &token); err != nil {
+		return fmt.Errorf("token: %w", err)
+	}
+	if token.AccessToken == "" {
+		return errors.New("token: no access_token")
+	}
+	cc.token = token.AccessToken
+	cc.expiry = time.Time{}
+	if token.ExpiresIn > 0 {
+		cc.expiry = time.Now().Add(time.Duration(token.ExpiresIn) * time.Second)
+	}
+	return nil
+}
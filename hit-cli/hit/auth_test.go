@@ -0,0 +1,126 @@
+package hit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAuth(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]struct {
+		auth Auth
+		want string
+	}{
+		"basic":  {auth: BasicAuth("gopher", "secret"), want: "Basic Z29waGVyOnNlY3JldA=="},
+		"bearer": {auth: BearerToken("t0k3n"), want: "Bearer t0k3n"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			r := newRequest(t, http.MethodGet, "http://go.dev")
+			if err := tt.auth.Authorize(r); err != nil {
+				t.Fatalf("Authorize() err=%q; want nil", err)
+			}
+			if got := r.Header.Get("Authorization"); got != tt.want {
+				t.Errorf("Authorization=%q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// newTokenServer returns a token server that issues tokens that expire
+// in expiresIn seconds after a delay, and counts the tokens it issues.
+func newTokenServer(t *testing.T, expiresIn int, delay time.Duration) (*ClientCredentials, *atomic.Int64) {
+	t.Helper()
+
+	var issued atomic.Int64
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		id, secret, _ := r.BasicAuth()
+		if r.FormValue("grant_type") != "client_credentials" || id != "hit" || secret != "s3cret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error":"invalid_client"}`)
+			return
+		}
+		time.Sleep(delay)
+		fmt.Fprintf(w, `{"access_token":"t%d","token_type":"Bearer","expires_in":%d}`, issued.Add(1), expiresIn)
+	})
+	return &ClientCredentials{
+		TokenURL:     server.URL,
+		ClientID:     "hit",
+		ClientSecret: "s3cret",
+	}, &issued
+}
+
+func TestClientCredentials(t *testing.T) {
+	t.Parallel()
+
+	const (
+		n     = 20
+		delay = 100 * time.Millisecond
+	)
+
+	cc, issued := newTokenServer(t, 3600, delay)
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer t1" {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+
+	client := &Client{C: 4, Auth: cc}
+	sum := client.Do(context.Background(), newRequest(t, http.MethodGet, server.URL), n)
+	if got := sum.Errors; got != 0 {
+		t.Errorf("Errors=%d; want 0", got)
+	}
+	if got := issued.Load(); got != 1 {
+		t.Errorf("issued %d tokens; want 1", got)
+	}
+	if sum.Slowest >= delay {
+		t.Errorf("Slowest=%s; want the token fetch excluded (< %s)", sum.Slowest, delay)
+	}
+}
+
+func TestClientCredentialsRefresh(t *testing.T) {
+	t.Parallel()
+
+	cc, issued := newTokenServer(t, 1, 0)
+	for i := 1; i <= 3; i++ {
+		r := newRequest(t, http.MethodGet, "http://go.dev")
+		if err := cc.Authorize(r); err != nil {
+			t.Fatalf("Authorize() err=%q; want nil", err)
+		}
+		if got, want := r.Header.Get("Authorization"), fmt.Sprintf("Bearer t%d", i); got != want {
+			t.Errorf("Authorization=%q; want %q", got, want)
+		}
+	}
+	if got := issued.Load(); got != 3 {
+		t.Errorf("issued %d tokens; want 3 for tokens about to expire", got)
+	}
+}
+
+func TestClientCredentialsError(t *testing.T) {
+	t.Parallel()
+
+	cc, _ := newTokenServer(t, 3600, 0)
+	cc.ClientSecret = "wrong"
+
+	var sent atomic.Int64
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		sent.Add(1)
+	})
+
+	client := &Client{C: 2, Auth: cc}
+	sum := client.Do(context.Background(), newRequest(t, http.MethodGet, server.URL), 4)
+	if got := sum.Errors; got != 4 {
+		t.Errorf("Errors=%d; want 4", got)
+	}
+	if got := sent.Load(); got != 0 {
+		t.Errorf("sent %d requests; want none without a token", got)
+	}
+}
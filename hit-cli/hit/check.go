@@ -0,0 +1,142 @@
+package hit
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"regexp"
+)
+
+// maxSamples is the number of failing responses a Result keeps.
+const maxSamples = 10
+
+// maxSampleBody is the longest body a Sample keeps.
+const maxSampleBody = 1 << 10
+
+// Response is a response that a Check validates.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte // Body is truncated to 4 MiB
+	Size   int64  // Size is the whole body's size in bytes
+
+	statusOK *bool // statusOK is the verdict of a StatusIn check, if any
+}
+
+// Check validates a response and returns an error that describes why
+// the response is not valid.
+type Check func(*Response) error
+
+// Sample is a response that failed a check.
+type Sample struct {
+	Endpoint string
+	Status   int
+	Header   http.Header
+	Body     []byte // Body is truncated to a KiB
+	Reason   string // Reason is the failed check's error
+}
+
+// StatusIn checks that the response status is one of the codes. The
+// codes, rather than the statuses of 400 and up, are then the statuses
+// that count as successes.
+func StatusIn(codes ...int) Check {
+	return func(r *Response) error {
+		ok := false
+		for _, c := range codes {
+			ok = ok || r.Status == c
+		}
+		if r.statusOK == nil || !ok {
+			r.statusOK = &ok
+		}
+		if !ok {
+			return fmt.Errorf("status %d not in %v", r.Status, codes)
+		}
+		return nil
+	}
+}
+
+// BodyContains checks that the response body contains s.
+func BodyContains(s string) Check {
+	return func(r *Response) error {
+		if !bytes.Contains(r.Body, []byte(s)) {
+			return fmt.Errorf("body does not contain %q", s)
+		}
+		return nil
+	}
+}
+
+// BodyMatches checks that the response body matches re.
+func BodyMatches(re *regexp.Regexp) Check {
+	return func(r *Response) error {
+		if !re.Match(r.Body) {
+			return fmt.Errorf("body does not match %q", re)
+		}
+		return nil
+	}
+}
+
+// JSONField checks that the JSON field at a dot separated path of the
+// response body equals want. Numbers in the path index arrays.
+func JSONField(path, want string) Check {
+	return func(r *Response) error {
+		got, err := extractJSON(r.Body, path)
+		if err != nil {
+			return fmt.Errorf("json: %w", err)
+		}
+		if got != want {
+			return fmt.Errorf("json %s=%q; want %q", path, got, want)
+		}
+		return nil
+	}
+}
+
+// HeaderPresent checks that the response has the header.
+func HeaderPresent(name string) Check {
+	return func(r *Response) error {
+		if _, ok := r.Header[http.CanonicalHeaderKey(name)]; !ok {
+			return fmt.Errorf("no %s header", name)
+		}
+		return nil
+	}
+}
+
+// MaxBodySize checks that the response body is at most n bytes.
+func MaxBodySize(n int) Check {
+	return func(r *Response) error {
+		if r.Size > int64(n) {
+			return fmt.Errorf("body is %d bytes; want at most %d", r.Size, n)
+		}
+		return nil
+	}
+}
+
+// check runs the checks against the response to r. It records the first
+// failed check in the result with a sample of the response, and the
+// verdict of the StatusIn checks.
+func check(checks []Check, r *http.Request, result *Result, header http.Header, body []byte) {
+	response := &Response{Status: result.Status, Header: header, Body: body, Size: result.Bytes}
+	for _, c := range checks {
+		err := c(response)
+		if err == nil || result.CheckError != nil {
+			// run the next checks for their verdict on the status
+			continue
+		}
+		if len(body) > maxSampleBody {
+			body = body[:maxSampleBody]
+		}
+		body = append([]byte(nil), body...) // don't keep the whole body
+		name := result.Endpoint
+		if name == "" {
+			name = r.Method + " " + r.URL.String()
+		}
+		result.CheckError = err
+		result.sample = &Sample{
+			Endpoint: name,
+			Status:   result.Status,
+			Header:   header,
+			Body:     body,
+			Reason:   err.Error(),
+		}
+	}
+	result.statusOK = response.statusOK
+}
@@ -0,0 +1,136 @@
+package hit
+
+import (
+	"context"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestChecks(t *testing.T) {
+	t.Parallel()
+
+	response := &Response{
+		Status: http.StatusCreated,
+		Header: http.Header{"Location": {"/r/abc"}},
+		Body:   []byte(`{"key":"abc","n":1}`),
+		Size:   19,
+	}
+	tests := map[string]struct {
+		check Check
+		pass  bool
+	}{
+		"status/pass":   {StatusIn(200, 201), true},
+		"status/fail":   {StatusIn(200), false},
+		"contains/pass": {BodyContains(`"abc"`), true},
+		"contains/fail": {BodyContains("xyz"), false},
+		"matches/pass":  {BodyMatches(regexp.MustCompile(`"key":"\w+"`)), true},
+		"matches/fail":  {BodyMatches(regexp.MustCompile(`^\[`)), false},
+		"json/pass":     {JSONField("key", "abc"), true},
+		"json/number":   {JSONField("n", "1"), true},
+		"json/fail":     {JSONField("key", "xyz"), false},
+		"json/missing":  {JSONField("nope", "abc"), false},
+		"header/pass":   {HeaderPresent("location"), true},
+		"header/fail":   {HeaderPresent("ETag"), false},
+		"size/pass":     {MaxBodySize(100), true},
+		"size/fail":     {MaxBodySize(10), false},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if err := tt.check(response); (err == nil) != tt.pass {
+				t.Errorf("check err=%v; want pass=%t", err, tt.pass)
+			}
+		})
+	}
+}
+
+func TestClientDoChecks(t *testing.T) {
+	t.Parallel()
+
+	const hits = 20
+
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("x", 2*maxSampleBody)))
+	})
+
+	c := &Client{
+		C:      2,
+		Checks: []Check{StatusIn(http.StatusOK), BodyContains("ok")},
+	}
+	sum := c.Do(context.Background(), newRequest(t, http.MethodGet, server.URL), hits)
+	if sum.Errors != 0 {
+		t.Errorf("Errors=%d; want 0", sum.Errors)
+	}
+	if sum.CheckFailures != hits {
+		t.Errorf("CheckFailures=%d; want %d", sum.CheckFailures, hits)
+	}
+	if got := len(sum.Samples); got != maxSamples {
+		t.Fatalf("Samples=%d; want %d", got, maxSamples)
+	}
+	s := sum.Samples[0]
+	if s.Status != http.StatusOK || len(s.Body) != maxSampleBody || !strings.Contains(s.Reason, "ok") {
+		t.Errorf("Sample=%d, %d bytes, %q; want 200, %d bytes and the failed check",
+			s.Status, len(s.Body), s.Reason, maxSampleBody)
+	}
+	if sum.Bytes != hits*2*maxSampleBody {
+		t.Errorf("Bytes=%d; want %d", sum.Bytes, hits*2*maxSampleBody)
+	}
+}
+
+func TestClientDoStatusCheck(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		http.NotFound(w, r)
+	})
+	tests := map[string]struct {
+		checks []Check
+		errors int
+	}{
+		"default":  {nil, 2},
+		"expected": {[]Check{StatusIn(http.StatusNotFound)}, 0},
+		"rejected": {[]Check{StatusIn(http.StatusOK)}, 2},
+		"first":    {[]Check{BodyContains("xyz"), StatusIn(http.StatusNotFound)}, 0},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			c := &Client{C: 1, Checks: tt.checks}
+			sum := c.Do(context.Background(), newRequest(t, http.MethodGet, server.URL), 2)
+			if sum.Errors != tt.errors {
+				t.Errorf("Errors=%d; want %d", sum.Errors, tt.errors)
+			}
+		})
+	}
+}
+
+func TestClientDoMaxBodySize(t *testing.T) {
+	t.Parallel()
+
+	const size = maxBody + 10
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(strings.Repeat("x", size)))
+	})
+
+	var got int
+	c := &Client{
+		C: 1,
+		Checks: []Check{MaxBodySize(size - 1), func(r *Response) error {
+			got = len(r.Body)
+			return nil
+		}},
+	}
+	sum := c.Do(context.Background(), newRequest(t, http.MethodGet, server.URL), 1)
+	if sum.Bytes != size || sum.CheckFailures != 1 {
+		t.Errorf("Bytes=%d, CheckFailures=%d; want %d, 1", sum.Bytes, sum.CheckFailures, size)
+	}
+	if got != maxBody {
+		t.Errorf("checked a body of %d bytes; want %d", got, maxBody)
+	}
+}
@@ -2,9 +2,13 @@ package hit
 
 import (
 	"context"
+	"crypto/tls"
 	"fmt"
+	"math"
 	"net/http"
 	"runtime"
+	"strconv"
+	"sync/atomic"
 	"time"
 )
 
@@ -14,6 +18,67 @@ type Client struct {
 	C       int // C is the concurrency level
 	RPS     int // RPS throttles the requests per second
 	Timeout time.Duration
+
+	// Stages is a load profile that changes the request rate over time,
+	// or with the StageConcurrency mode, the number of concurrent workers.
+	// It overrides RPS, and C in StageConcurrency mode, and ends the run
+	// when the last stage ends.
+	Stages    Stages
+	StageMode StageMode
+
+	// OnInterval is called with a snapshot of the run's progress every
+	// Interval, and once more when the run ends. Interval defaults to
+	// a second. The result keeps the snapshots in its Intervals.
+	OnInterval func(Snapshot)
+	Interval   time.Duration
+
+	// OnResult is called with each request's result.
+	OnResult func(*Result)
+
+	// TLS configures the connections to HTTPS targets. See TLSOptions.
+	TLS *tls.Config
+
+	// Protocol is the HTTP protocol to send requests with. MaxStreams
+	// limits the concurrent HTTP/2 streams per connection: the Client
+	// opens as many connections as the concurrency level needs.
+	Protocol   Protocol
+	MaxStreams int
+
+	// Thresholds are evaluated against the aggregated result into its
+	// Thresholds.
+	Thresholds []Threshold
+
+	// Checks validate each response. Failed checks are counted apart
+	// from the errors.
+	Checks []Check
+
+	// Retry retries the failed requests. The result of a retried request
+	// is its last attempt's with the duration of all its attempts.
+	Retry *RetryPolicy
+
+	// Sessions gives each virtual user its own cookie jar, and Setup
+	// prepares each virtual user before it sends requests, such as to
+	// log in. See VU.
+	Sessions bool
+	Setup    func(ctx context.Context, vu *VU) error
+
+	// Auth authorizes each request. The time it takes, such as to fetch
+	// a token, is not part of the request's duration.
+	Auth Auth
+
+	// WarmUp requests, and the requests that start within WarmUpDuration
+	// of the run's start, are sent but not merged into the result, so
+	// that the connections and the target's caches warm up without
+	// skewing the statistics. The result's WarmUp aggregates them apart.
+	// The number of requests to send includes them.
+	WarmUp         int
+	WarmUpDuration time.Duration
+
+	// Middleware wraps the sending of each request, after its virtual
+	// user's headers and authorization and around its retries. The
+	// first middleware is the outermost. Each virtual user chains the
+	// middleware once. See Use.
+	Middleware []Middleware
 }
 
 // Option changes the Client's behavior.
@@ -29,6 +94,53 @@ func Timeout(d time.Duration) Option {
 	return func(c *Client) { c.Timeout = d }
 }
 
+// Profile changes the Client's load profile to the stages.
+func Profile(stages ...Stage) Option {
+	return func(c *Client) { c.Stages = stages }
+}
+
+// Progress calls fn with a snapshot of the run's progress every d.
+func Progress(d time.Duration, fn func(Snapshot)) Option {
+	return func(c *Client) {
+		c.Interval = d
+		c.OnInterval = fn
+	}
+}
+
+// Record calls fn with each request's result.
+func Record(fn func(*Result)) Option {
+	return func(c *Client) { c.OnResult = fn }
+}
+
+// TLS changes the Client's TLS configuration.
+func TLS(cfg *tls.Config) Option {
+	return func(c *Client) { c.TLS = cfg }
+}
+
+// HTTP changes the Client's protocol and its HTTP/2 streams per
+// connection.
+func HTTP(p Protocol, maxStreams int) Option {
+	return func(c *Client) {
+		c.Protocol = p
+		c.MaxStreams = maxStreams
+	}
+}
+
+// Thresholds changes the Client's pass or fail thresholds.
+func Thresholds(ts ...Threshold) Option {
+	return func(c *Client) { c.Thresholds = ts }
+}
+
+// Checks changes the Client's response checks.
+func Checks(checks ...Check) Option {
+	return func(c *Client) { c.Checks = checks }
+}
+
+// Retry changes the Client's retry policy.
+func Retry(p RetryPolicy) Option {
+	return func(c *Client) { c.Retry = &p }
+}
+
 // Do sends n GET requests to the url usig as many goroutines as the
 // number of CPUs on the machine and returns an aggregated result.
 func Do(ctx context.Context, url string, n int, opts ...Option) (*Result, error) {
@@ -43,51 +155,288 @@ func Do(ctx context.Context, url string, n int, opts ...Option) (*Result, error)
 	return c.Do(ctx, r, n), nil
 }
 
-// Do sends an HTTP request and returns an aggregated result.
+// Do sends an HTTP request n times and returns an aggregated result.
+// If the Client has Stages, Do stops when the last stage ends, and n
+// limits the number of requests only if it is positive.
 func (c *Client) Do(ctx context.Context, r *http.Request, n int, opts ...Option) *Result {
+	return c.Run(ctx, Clone(r), n)
+}
+
+// Run sends n requests from the source and returns an aggregated result.
+// Run stops like Do when the Client has Stages.
+func (c *Client) Run(ctx context.Context, src Source, n int) *Result {
+	return c.run(ctx, src, n, c.send)
+}
+
+// RunScenario runs n iterations of the scenario and returns an
+// aggregated result. The result's Requests counts the iterations, and
+// its Endpoints have the results of each step. RunScenario stops like
+// Do when the Client has Stages.
+func (c *Client) RunScenario(ctx context.Context, sc *Scenario, n int) (*Result, error) {
+	src, send, err := c.scenario(sc)
+	if err != nil {
+		return nil, err
+	}
+	return c.run(ctx, src, n, send), nil
+}
+
+// scenario returns the source of the scenario's iterations and the
+// function that runs them as a virtual user.
+func (c *Client) scenario(sc *Scenario) (Source, func(*VU) SendFunc, error) {
+	if err := sc.init(); err != nil {
+		return nil, nil, err
+	}
+	src, err := Feed(sc.Feeder, sc.Steps[0].Template)
+	if err != nil {
+		return nil, nil, err
+	}
+	return src, func(vu *VU) SendFunc {
+		return sc.send(vu, c.exchange(vu))
+	}, nil
+}
+
+// run sends n requests from the source with the function that send
+// returns and returns an aggregated result.
+func (c *Client) run(ctx context.Context, src Source, n int, send func(*VU) SendFunc) *Result {
 	t := time.Now()
-	sum := c.do(ctx, r, n)
-	return sum.Finalize(time.Since(t))
+	sum := c.do(ctx, src, n, send)
+	total := time.Since(t)
+	if sum.WarmUp != nil {
+		total -= sum.WarmUp.Duration
+	}
+	sum.Finalize(total)
+	for _, th := range c.Thresholds {
+		sum.Thresholds = append(sum.Thresholds, th.Eval(sum))
+	}
+	return sum
 }
 
-// clone the request to let the producer produce identical request values to
-// send HTTP requests to the same URL. If request values were not cloned, there
-// would be clashes with other req values bc each req is stateful.
-// https://pkg.go.dev/net/http#Request.Clone
-func (c *Client) do(ctx context.Context, r *http.Request, n int) *Result {
-	p := produce(ctx, n, func() *http.Request {
-		return r.Clone(ctx)
+func (c *Client) do(ctx context.Context, src Source, n int, send func(*VU) SendFunc) *Result {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	if len(c.Stages) > 0 && n <= 0 {
+		n = math.MaxInt
+	}
+	var (
+		mon      = startMonitor()
+		begin    = time.Now()
+		wait     func(id int)
+		staged   = len(c.Stages) > 0 && c.StageMode == StageConcurrency
+		produced int
+	)
+	// the waiting workers stop waiting when the source runs out
+	gateCtx, ungate := context.WithCancel(ctx)
+	defer ungate()
+	if staged {
+		wait = c.Stages.gate(gateCtx, begin)
+	}
+	p := produce(ctx, n, func() (*http.Request, bool) {
+		if staged && time.Since(begin) >= c.Stages.Duration() {
+			// let the workers finish the requests in flight
+			ungate()
+			return nil, false
+		}
+		r := src.Next(ctx)
+		if produced++; r == nil || produced == n {
+			ungate()
+		}
+		return r, r != nil
 	})
-	if c.RPS > 0 {
-		p = throttle(p, time.Second/time.Duration(c.RPS*c.concurrency()))
+	switch {
+	case staged:
+		// the workers send as fast as they can
+	case len(c.Stages) > 0:
+		p = ramp(p, c.Stages, mon.behind)
+	case c.RPS > 0:
+		p = throttle(p, time.Second/time.Duration(c.RPS*c.concurrency()), mon.behind)
 	}
 	var (
-		sum    Result
-		client = c.client()
+		sum       Result
+		active    atomic.Int64
+		transport = c.transport()
+	)
+	defer closeIdleConnections(transport)
+	results := split(p, c.concurrency(), func(id int) func(*http.Request) *Result {
+		vu, err := c.vu(ctx, id, transport)
+		if err != nil {
+			return fail(err)
+		}
+		send := send(vu)
+		return func(r *http.Request) *Result {
+			active.Add(1)
+			defer active.Add(-1)
+			return send(r)
+		}
+	}, wait)
+
+	t := time.NewTicker(c.interval())
+	defer t.Stop()
+	var (
+		start    = time.Now()
+		iv       = newInterval(start)
+		warm     Result
+		warmEnd  time.Time
+		received int
 	)
-	defer client.CloseIdleConnections()
-	for result := range split(p, c.concurrency(), c.send(client)) {
-		sum.Merge(result)
+	snapshot := func(now time.Time) {
+		s := iv.snapshot(now)
+		s.Active = int(active.Load())
+		sum.Intervals = append(sum.Intervals, s)
+		if c.OnInterval != nil {
+			c.OnInterval(s)
+		}
+	}
+	for {
+		select {
+		case result, ok := <-results:
+			if !ok {
+				snapshot(time.Now())
+				sum.Saturation = mon.stop(received)
+				if warm.Requests > 0 {
+					sum.WarmUp = warm.Finalize(warmEnd.Sub(start))
+				}
+				return &sum
+			}
+			received++
+			if c.warmingUp(received, result.Start.Sub(start)) {
+				result.WarmingUp = true
+				warmEnd = time.Now()
+				c.merge(&warm, result)
+			} else {
+				c.merge(&sum, result)
+			}
+			iv.add(result)
+		case now := <-t.C:
+			snapshot(now)
+		}
+	}
+}
+
+// merge a request's result into sum.
+func (c *Client) merge(sum, r *Result) {
+	r.redact()
+	if c.OnResult != nil {
+		c.OnResult(r)
 	}
-	return &sum
+	sum.Merge(r)
 }
 
-func (c *Client) send(client *http.Client) SendFunc {
+func (c *Client) send(vu *VU) SendFunc {
+	exchange := c.exchange(vu)
 	return func(r *http.Request) *Result {
-		return Send(client, r)
+		result, _, _ := exchange(r, false)
+		return result
+	}
+}
+
+// exchange returns a function that sends requests as the virtual user,
+// retries them and checks their responses.
+func (c *Client) exchange(vu *VU) exchangeFunc {
+	send := c.chain(vu.client())
+	return func(r *http.Request, keep bool) (*Result, http.Header, []byte) {
+		if err := requestError(r.Context()); err != nil {
+			return fail(err)(r), nil, nil
+		}
+		r = vu.prepare(r)
+		if c.Auth != nil {
+			if err := c.Auth.Authorize(r); err != nil {
+				return fail(fmt.Errorf("auth: %w", err))(r), nil, nil
+			}
+		}
+		keep = keep || len(c.Checks) > 0
+		result, header, body := send(r, keep)
+		if result.Error == nil && len(c.Checks) > 0 {
+			check(c.Checks, r, result, header, body)
+		}
+		return result, header, body
+	}
+}
+
+// retry sends a request until it succeeds or the retry policy gives up.
+func (c *Client) retry(client *http.Client, r *http.Request, keep bool) (*Result, http.Header, []byte) {
+	result, header, body := send(client, r, keep)
+	if c.Retry == nil {
+		return result, header, body
+	}
+	start := result.Start
+	for attempt := 1; c.Retry.retry(attempt, result); attempt++ {
+		if sleep(r.Context(), c.Retry.delay(attempt, header)) != nil {
+			break
+		}
+		next, err := rewind(r)
+		if err != nil {
+			break
+		}
+		result, header, body = send(client, next, keep)
+		result.Attempts = attempt + 1
+	}
+	result.Start = start
+	result.Duration = time.Since(start)
+	return result, header, body
+}
+
+// rewind returns a copy of a sent request to send it again.
+func rewind(r *http.Request) (*http.Request, error) {
+	next := r.Clone(r.Context())
+	if r.GetBody == nil {
+		return next, nil
+	}
+	body, err := r.GetBody()
+	next.Body = body
+	return next, err
+}
+
+func (c *Client) transport() http.RoundTripper {
+	if c.Protocol == HTTP2 || c.Protocol == H2C {
+		return newH2Pool(c.Protocol, c.TLS, c.concurrency(), c.MaxStreams)
+	}
+	return &http.Transport{
+		MaxIdleConnsPerHost: c.concurrency(),
+		TLSClientConfig:     c.TLS,
+		// a non-nil empty map disables negotiating HTTP/2 over TLS
+		TLSNextProto: map[string]func(string, *tls.Conn) http.RoundTripper{},
 	}
 }
 
-func (c *Client) client() *http.Client {
-	return &http.Client{
-		Timeout: c.Timeout,
-		Transport: &http.Transport{
-			MaxIdleConnsPerHost: c.concurrency(),
-		},
+func closeIdleConnections(t http.RoundTripper) {
+	if t, ok := t.(interface{ CloseIdleConnections() }); ok {
+		t.CloseIdleConnections()
 	}
 }
 
+// warmingUp reports whether the nth request, which started elapsed
+// after the run's start, is a warm-up request.
+func (c *Client) warmingUp(n int, elapsed time.Duration) bool {
+	return n <= c.WarmUp || elapsed < c.WarmUpDuration
+}
+
+// ParseWarmUp parses a warm-up as a number of requests, such as 100, or
+// as a duration, such as 10s.
+func ParseWarmUp(s string) (requests int, d time.Duration, err error) {
+	if n, err := strconv.Atoi(s); err == nil && n >= 0 {
+		return n, 0, nil
+	}
+	if d, err := time.ParseDuration(s); err == nil && d >= 0 {
+		return 0, d, nil
+	}
+	return 0, 0, fmt.Errorf("warm-up %q: want a number of requests or a duration", s)
+}
+
+func (c *Client) interval() time.Duration {
+	if c.Interval > 0 {
+		return c.Interval
+	}
+	return time.Second
+}
+
 func (c *Client) concurrency() int {
+	if len(c.Stages) > 0 && c.StageMode == StageConcurrency {
+		if n := c.Stages.Max(); n > 0 {
+			return n
+		}
+		return 1
+	}
 	if c.C > 0 {
 		return c.C
 	}
@@ -6,6 +6,7 @@ import (
 	"net/http/httptest"
 	"sync/atomic"
 	"testing"
+	"time"
 )
 
 func TestClientDo(t *testing.T) {
@@ -39,6 +40,87 @@ func TestClientDo(t *testing.T) {
 	}
 }
 
+func TestClientDoProgress(t *testing.T) {
+	t.Parallel()
+
+	const hits = 20
+
+	var (
+		server = newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			time.Sleep(10 * time.Millisecond)
+		})
+		request   = newRequest(t, http.MethodGet, server.URL)
+		snapshots []Snapshot
+	)
+
+	c := &Client{
+		C:        2,
+		Interval: 20 * time.Millisecond,
+		OnInterval: func(s Snapshot) {
+			snapshots = append(snapshots, s)
+		},
+	}
+	sum := c.Do(context.Background(), request, hits)
+
+	if len(snapshots) < 2 {
+		t.Fatalf("snapshots=%d; want >1", len(snapshots))
+	}
+	var requests, ok int
+	for _, s := range snapshots {
+		requests += s.Requests
+		ok += s.Statuses[http.StatusOK]
+	}
+	if requests != hits {
+		t.Errorf("sum of snapshot requests=%d; want %d", requests, hits)
+	}
+	if ok != hits {
+		t.Errorf("sum of snapshot 200 statuses=%d; want %d", ok, hits)
+	}
+	if got := len(sum.Intervals); got != len(snapshots) {
+		t.Errorf("Intervals=%d; want %d", got, len(snapshots))
+	}
+	if last := snapshots[len(snapshots)-1]; last.Total != hits {
+		t.Errorf("last snapshot Total=%d; want %d", last.Total, hits)
+	}
+}
+
+func TestClientWarmUp(t *testing.T) {
+	t.Parallel()
+
+	const hits, warmUp = 10, 3
+
+	var (
+		server  = newTestServer(t, func(w http.ResponseWriter, r *http.Request) {})
+		request = newRequest(t, http.MethodGet, server.URL)
+		warming int
+	)
+	c := &Client{
+		C:      1,
+		WarmUp: warmUp,
+		OnResult: func(r *Result) {
+			if r.WarmingUp {
+				warming++
+			}
+		},
+	}
+	sum := c.Do(context.Background(), request, hits)
+
+	if got, want := sum.Requests, hits-warmUp; got != want {
+		t.Errorf("Requests=%d; want %d without the warm-up", got, want)
+	}
+	if sum.WarmUp == nil || sum.WarmUp.Requests != warmUp {
+		t.Fatalf("WarmUp=%+v; want %d requests", sum.WarmUp, warmUp)
+	}
+	if warming != warmUp {
+		t.Errorf("OnResult got %d warm-up results; want %d", warming, warmUp)
+	}
+
+	c = &Client{C: 1, WarmUpDuration: time.Hour}
+	if sum = c.Do(context.Background(), request, hits); sum.Requests != 0 || sum.WarmUp.Requests != hits {
+		t.Errorf("Requests=%d; want all %d within the warm-up duration", sum.Requests, hits)
+	}
+}
+
 func newTestServer(tb testing.TB, h http.HandlerFunc) *httptest.Server {
 	tb.Helper()
 	s := httptest.NewServer(h)
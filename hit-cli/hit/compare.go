@@ -0,0 +1,167 @@
+package hit
+
+import (
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"time"
+)
+
+// maxLatencySample is the number of latencies that the JSON summary and
+// Compare sample from a result.
+const maxLatencySample = 1000
+
+// significance is the p-value under which Compare considers that the
+// latencies of two results differ.
+const significance = 0.05
+
+// Comparison is the comparison of a result with a baseline result.
+type Comparison struct {
+	// Deltas are the changes of the RPS, the error rate and the latency
+	// percentiles.
+	Deltas []Delta
+
+	// P is the p-value of a Mann-Whitney U test on the sampled latencies
+	// of the results: the probability that the latencies differ as much
+	// as they do by chance. Significant reports whether P is under 0.05.
+	P           float64
+	Significant bool
+}
+
+// Delta is the change of a metric between two results.
+type Delta struct {
+	Metric   string
+	Old, New float64
+
+	// Worse reports whether the change is a regression, such as a lower
+	// RPS or a higher latency.
+	Worse bool
+}
+
+// Change returns the change in percent of the old value. It is zero if
+// the old value is zero.
+func (d Delta) Change() float64 {
+	if d.Old == 0 {
+		return 0
+	}
+	return (d.New - d.Old) / d.Old * 100
+}
+
+// Compare the result with a baseline result. Both results should be
+// finalized.
+func (r *Result) Compare(baseline *Result) *Comparison {
+	c := &Comparison{
+		Deltas: []Delta{
+			{Metric: "rps", Old: baseline.RPS, New: r.RPS},
+			{Metric: "errors_%", Old: 100 - baseline.success(), New: 100 - r.success()},
+			{Metric: "p50_ms", Old: ms(baseline.P50), New: ms(r.P50)},
+			{Metric: "p90_ms", Old: ms(baseline.P90), New: ms(r.P90)},
+			{Metric: "p99_ms", Old: ms(baseline.P99), New: ms(r.P99)},
+		},
+	}
+	for i := range c.Deltas {
+		d := &c.Deltas[i]
+		d.Worse = d.New > d.Old
+		if d.Metric == "rps" {
+			d.Worse = d.New < d.Old
+		}
+	}
+	c.P = mannWhitney(
+		sampleLatencies(baseline.latencies, maxLatencySample),
+		sampleLatencies(r.latencies, maxLatencySample),
+	)
+	c.Significant = c.P < significance
+	return c
+}
+
+// Fprint the comparison to an io.Writer.
+func (c *Comparison) Fprint(out io.Writer) {
+	p := func(format string, args ...any) {
+		fmt.Fprintf(out, format, args...)
+	}
+	p("\nComparison:\n")
+	p("\t%-10s %12s %12s %9s\n", "", "old", "new", "change")
+	for _, d := range c.Deltas {
+		verdict := ""
+		if d.Worse && d.New != d.Old {
+			verdict = " (worse)"
+		}
+		p("\t%-10s %12.2f %12.2f %+8.1f%%%s\n", d.Metric, d.Old, d.New, d.Change(), verdict)
+	}
+	if c.Significant {
+		p("\nThe latencies differ significantly (p=%.4f).\n", c.P)
+	} else {
+		p("\nThe latencies don't differ significantly (p=%.4f).\n", c.P)
+	}
+}
+
+// sampleLatencies returns up to n latencies evenly spaced across the
+// sorted latencies. It sorts ds.
+func sampleLatencies(ds []time.Duration, n int) []time.Duration {
+	sort.Slice(ds, func(i, j int) bool { return ds[i] < ds[j] })
+	if len(ds) <= n {
+		return ds
+	}
+	sample := make([]time.Duration, n)
+	for i := range sample {
+		sample[i] = ds[i*len(ds)/n]
+	}
+	return sample
+}
+
+// mannWhitney returns the two-sided p-value of the Mann-Whitney U test
+// that the samples come from the same distribution. It uses the normal
+// approximation with a tie correction, and returns 1 if a sample is
+// empty or all the latencies are equal.
+func mannWhitney(a, b []time.Duration) float64 {
+	n1, n2 := float64(len(a)), float64(len(b))
+	if n1 == 0 || n2 == 0 {
+		return 1
+	}
+
+	type value struct {
+		d     time.Duration
+		fromA bool
+	}
+	all := make([]value, 0, len(a)+len(b))
+	for _, d := range a {
+		all = append(all, value{d, true})
+	}
+	for _, d := range b {
+		all = append(all, value{d, false})
+	}
+	sort.Slice(all, func(i, j int) bool { return all[i].d < all[j].d })
+
+	// rank the values, giving the ties their average rank
+	var rankA, ties float64
+	for i := 0; i < len(all); {
+		j := i
+		for j < len(all) && all[j].d == all[i].d {
+			j++
+		}
+		rank := float64(i+j+1) / 2
+		for k := i; k < j; k++ {
+			if all[k].fromA {
+				rankA += rank
+			}
+		}
+		t := float64(j - i)
+		ties += t*t*t - t
+		i = j
+	}
+
+	n := n1 + n2
+	u := rankA - n1*(n1+1)/2
+	mean := n1 * n2 / 2
+	sd := math.Sqrt(n1 * n2 / 12 * ((n + 1) - ties/(n*(n-1))))
+	if sd == 0 {
+		return 1
+	}
+	// continuity correction
+	z := (math.Abs(u-mean) - 0.5) / sd
+	if z < 0 {
+		z = 0
+	}
+	return math.Erfc(z / math.Sqrt2)
+}
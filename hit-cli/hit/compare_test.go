@@ -0,0 +1,103 @@
+package hit
+
+import (
+	"bytes"
+	"math"
+	"testing"
+	"time"
+)
+
+// newLatencyResult returns a finalized result of n requests whose
+// latencies start at from and grow by step.
+func newLatencyResult(n int, from, step time.Duration) *Result {
+	var sum Result
+	for i := 0; i < n; i++ {
+		sum.Merge(&Result{Duration: from + time.Duration(i)*step, Status: 200})
+	}
+	return sum.Finalize(time.Second)
+}
+
+func TestMannWhitney(t *testing.T) {
+	t.Parallel()
+
+	ms := func(vs ...int) []time.Duration {
+		ds := make([]time.Duration, len(vs))
+		for i, v := range vs {
+			ds[i] = time.Duration(v) * time.Millisecond
+		}
+		return ds
+	}
+	tests := map[string]struct {
+		a, b []time.Duration
+		want float64
+	}{
+		"apart":  {a: ms(1, 2, 3, 4, 5), b: ms(6, 7, 8, 9, 10), want: 0.0122},
+		"ties":   {a: ms(1, 2, 2, 3), b: ms(2, 3, 3, 4), want: 0.1720},
+		"same":   {a: ms(1, 1, 1), b: ms(1, 1), want: 1},
+		"empty":  {a: ms(1, 2), b: nil, want: 1},
+		"mixed":  {a: ms(1, 3, 5, 7), b: ms(2, 4, 6, 8), want: 0.6650},
+		"single": {a: ms(1), b: ms(2), want: 1},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := mannWhitney(tt.a, tt.b); math.Abs(got-tt.want) > 1e-4 {
+				t.Errorf("mannWhitney()=%.4f; want %.4f", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResultCompare(t *testing.T) {
+	t.Parallel()
+
+	var (
+		old    = newLatencyResult(100, 10*time.Millisecond, time.Millisecond)
+		noise  = newLatencyResult(100, 10*time.Millisecond+500*time.Microsecond, time.Millisecond)
+		slower = newLatencyResult(100, 30*time.Millisecond, time.Millisecond)
+	)
+
+	if c := noise.Compare(old); c.Significant {
+		t.Errorf("noise: Significant=true (p=%.4f); want false", c.P)
+	}
+	c := slower.Compare(old)
+	if !c.Significant {
+		t.Errorf("slower: Significant=false (p=%.4f); want true", c.P)
+	}
+	for _, d := range c.Deltas {
+		if d.Metric == "p50_ms" && (!d.Worse || d.Change() <= 0) {
+			t.Errorf("p50 delta=%+v; want worse with a positive change", d)
+		}
+		if d.Metric == "rps" && d.Worse {
+			t.Errorf("rps delta=%+v; want not worse for the same rps", d)
+		}
+	}
+
+	var out bytes.Buffer
+	c.Fprint(&out)
+	if !bytes.Contains(out.Bytes(), []byte("differ significantly")) {
+		t.Errorf("Fprint() =\n%s\nwant the verdict", out.String())
+	}
+}
+
+func TestReadSummary(t *testing.T) {
+	t.Parallel()
+
+	want := newLatencyResult(10, 10*time.Millisecond, time.Millisecond)
+	var out bytes.Buffer
+	if err := JSON.Report(&out, want); err != nil {
+		t.Fatalf("Report() err=%q; want nil", err)
+	}
+	got, err := ReadSummary(&out)
+	if err != nil {
+		t.Fatalf("ReadSummary() err=%q; want nil", err)
+	}
+	if got.Requests != want.Requests || got.P90 != want.P90 || got.RPS != want.RPS {
+		t.Errorf("ReadSummary()=%+v; want %+v", got, want)
+	}
+	if c := got.Compare(want); c.P != 1 {
+		t.Errorf("Compare(itself) P=%.4f; want 1", c.P)
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package hit
+
+import "time"
+
+// cpuTime returns -1 as the CPU time of the process is unknown.
+func cpuTime() time.Duration { return -1 }
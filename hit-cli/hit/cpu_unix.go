@@ -0,0 +1,17 @@
+//go:build unix
+
+package hit
+
+import (
+	"syscall"
+	"time"
+)
+
+// cpuTime returns the CPU time that the process used.
+func cpuTime() time.Duration {
+	var ru syscall.Rusage
+	if err := syscall.Getrusage(syscall.RUSAGE_SELF, &ru); err != nil {
+		return -1
+	}
+	return time.Duration(ru.Utime.Nano() + ru.Stime.Nano())
+}
@@ -0,0 +1,71 @@
+package hit
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// Error classes.
+const (
+	ErrTimeout    = "timeout"    // the request timed out
+	ErrDNS        = "dns"        // the host name didn't resolve
+	ErrRefused    = "refused"    // the server refused the connection
+	ErrReset      = "reset"      // the server reset or closed the connection
+	ErrTLS        = "tls"        // the TLS handshake or verification failed
+	ErrCanceled   = "canceled"   // the run was canceled
+	ErrConnection = "connection" // another network error
+	ErrOther      = "other"      // an error that's not about the network
+)
+
+// ErrorClass classifies a request's error as one of the Err classes.
+// It returns an empty string for a nil error.
+func ErrorClass(err error) string {
+	var (
+		dnsErr *net.DNSError
+		netErr net.Error
+		opErr  *net.OpError
+	)
+	switch {
+	case err == nil:
+		return ""
+	case errors.Is(err, context.Canceled):
+		return ErrCanceled
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		return ErrTimeout
+	case errors.As(err, &dnsErr):
+		return ErrDNS
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return ErrRefused
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.EPIPE), errors.Is(err, io.EOF),
+		errors.Is(err, io.ErrUnexpectedEOF):
+		return ErrReset
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return ErrTimeout
+	case isTLSError(err):
+		return ErrTLS
+	case errors.As(err, &opErr):
+		return ErrConnection
+	}
+	return ErrOther
+}
+
+// isTLSError reports whether err is about a TLS handshake or a server
+// certificate.
+func isTLSError(err error) bool {
+	var (
+		recordErr    tls.RecordHeaderError
+		authorityErr x509.UnknownAuthorityError
+		hostErr      x509.HostnameError
+		invalidErr   x509.CertificateInvalidError
+	)
+	return errors.As(err, &recordErr) || errors.As(err, &authorityErr) ||
+		errors.As(err, &hostErr) || errors.As(err, &invalidErr) ||
+		strings.Contains(err.Error(), "tls: ")
+}
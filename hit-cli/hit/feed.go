@@ -0,0 +1,175 @@
+package hit
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// Fields are the named values of a row that fill in the {{field}}
+// placeholders of a Template.
+type Fields map[string]string
+
+// placeholder matches a {{field}} placeholder.
+var placeholder = regexp.MustCompile(`{{\s*([\w.-]+)\s*}}`)
+
+// expand fills in the placeholders of s with the escaped fields. It
+// leaves the placeholders of missing fields as they are.
+func (f Fields) expand(s string, escape func(string) string) string {
+	if len(f) == 0 {
+		return s
+	}
+	return placeholder.ReplaceAllStringFunc(s, func(p string) string {
+		name := placeholder.FindStringSubmatch(p)[1]
+		v, ok := f[name]
+		if !ok {
+			return p
+		}
+		return escape(v)
+	})
+}
+
+// expandURL fills in the placeholders of a URL, escaping the fields
+// for the URL's path and query.
+func (f Fields) expandURL(s string) string {
+	path, query, ok := strings.Cut(s, "?")
+	path = f.expand(path, url.PathEscape)
+	if !ok {
+		return path
+	}
+	return path + "?" + f.expand(query, url.QueryEscape)
+}
+
+func noEscape(s string) string { return s }
+
+// FeedMode is the order a Feeder feeds its rows in.
+type FeedMode int
+
+// Feed modes.
+const (
+	Sequential FeedMode = iota // Sequential feeds each row once, in order
+	Circular                   // Circular feeds the rows in order, over and over
+	Random                     // Random feeds random rows
+)
+
+// ParseFeedMode converts a feed mode name to a FeedMode.
+func ParseFeedMode(s string) (FeedMode, error) {
+	switch s {
+	case "sequential":
+		return Sequential, nil
+	case "circular":
+		return Circular, nil
+	case "random":
+		return Random, nil
+	}
+	return 0, fmt.Errorf("unknown feed mode %q", s)
+}
+
+// Feeder feeds rows of fields to the requests of a Source. It's safe
+// for concurrent use.
+type Feeder struct {
+	Rows []Fields
+	Mode FeedMode
+
+	mu sync.Mutex
+	i  int // i is the index of the next row
+}
+
+// next returns the next row. It returns false if the feeder ran out
+// of rows.
+func (f *Feeder) next() (Fields, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if len(f.Rows) == 0 {
+		return nil, false
+	}
+	switch f.Mode {
+	case Random:
+		return f.Rows[rand.Intn(len(f.Rows))], true
+	case Circular:
+		f.i %= len(f.Rows)
+	}
+	if f.i >= len(f.Rows) {
+		return nil, false
+	}
+	row := f.Rows[f.i]
+	f.i++
+	return row, true
+}
+
+// ReadCSV reads rows from CSV data. The first record names the fields.
+func ReadCSV(r io.Reader) ([]Fields, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("read csv: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, errors.New("read csv: no header")
+	}
+	header, records := records[0], records[1:]
+	rows := make([]Fields, len(records))
+	for i, record := range records {
+		rows[i] = make(Fields, len(header))
+		for j, name := range header {
+			rows[i][name] = record[j]
+		}
+	}
+	return rows, nil
+}
+
+// ReadJSONL reads rows from JSON Lines data, one JSON object per line.
+func ReadJSONL(r io.Reader) ([]Fields, error) {
+	var rows []Fields
+	s := bufio.NewScanner(r)
+	for line := 1; s.Scan(); line++ {
+		if len(bytes.TrimSpace(s.Bytes())) == 0 {
+			continue
+		}
+		var obj map[string]json.RawMessage
+		if err := json.Unmarshal(s.Bytes(), &obj); err != nil {
+			return nil, fmt.Errorf("read jsonl: line %d: %w", line, err)
+		}
+		row := make(Fields, len(obj))
+		for name, v := range obj {
+			var str string
+			if json.Unmarshal(v, &str) != nil {
+				str = string(v) // numbers, booleans and nested values
+			}
+			row[name] = str
+		}
+		rows = append(rows, row)
+	}
+	if err := s.Err(); err != nil {
+		return nil, fmt.Errorf("read jsonl: %w", err)
+	}
+	return rows, nil
+}
+
+// LoadFeed reads the rows of a .csv or a .jsonl file.
+func LoadFeed(path string) ([]Fields, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	switch ext := filepath.Ext(path); ext {
+	case ".csv":
+		return ReadCSV(f)
+	case ".jsonl", ".ndjson":
+		return ReadJSONL(f)
+	default:
+		return nil, fmt.Errorf("%s: unknown feed format %q", path, ext)
+	}
+}
@@ -0,0 +1,125 @@
+package hit
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestFeed(t *testing.T) {
+	t.Parallel()
+
+	rows, err := ReadCSV(strings.NewReader("key,token\nabc,t1\na b,t2\n"))
+	if err != nil {
+		t.Fatalf("ReadCSV() err=%q; want nil", err)
+	}
+
+	var (
+		mu     sync.Mutex
+		got    []string
+		server = newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			mu.Lock()
+			got = append(got, r.URL.EscapedPath()+" "+r.Header.Get("Authorization"))
+			mu.Unlock()
+		})
+	)
+
+	src, err := Feed(&Feeder{Rows: rows}, Template{
+		URL:    server.URL + "/r/{{key}}",
+		Header: http.Header{"Authorization": {"Bearer {{token}}"}},
+	})
+	if err != nil {
+		t.Fatalf("Feed() err=%q; want nil", err)
+	}
+
+	c := &Client{C: 1}
+	sum := c.Run(context.Background(), src, 10)
+	if sum.Requests != len(rows) {
+		t.Errorf("Requests=%d; want %d, the number of rows", sum.Requests, len(rows))
+	}
+	sort.Strings(got)
+	want := []string{"/r/a%20b Bearer t2", "/r/abc Bearer t1"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("\ngot  %q\nwant %q", got, want)
+	}
+}
+
+func TestFeedBadRow(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t, func(http.ResponseWriter, *http.Request) {})
+	host := strings.TrimPrefix(server.URL, "http://")
+	rows := []Fields{{"h": host}, {"h": "a b"}, {"h": host}}
+	src, err := Feed(&Feeder{Rows: rows}, Template{Name: "bad", URL: "http://{{h}}/"})
+	if err != nil {
+		t.Fatalf("Feed() err=%q; want nil", err)
+	}
+
+	c := &Client{C: 1}
+	sum := c.Run(context.Background(), src, len(rows))
+	if sum.Requests != len(rows) || sum.Errors != 1 {
+		t.Errorf("Requests=%d, Errors=%d; want %d, 1: the bad row fails", sum.Requests, sum.Errors, len(rows))
+	}
+	if got := sum.Endpoints["bad"]; got == nil || got.Errors != 1 {
+		t.Errorf("Endpoints[bad]=%+v; want the bad row's error", got)
+	}
+}
+
+func TestFeederModes(t *testing.T) {
+	t.Parallel()
+
+	rows := []Fields{{"k": "1"}, {"k": "2"}}
+	tests := map[FeedMode][]string{
+		Sequential: {"1", "2"},
+		Circular:   {"1", "2", "1", "2", "1"},
+	}
+	for mode, want := range tests {
+		f := &Feeder{Rows: rows, Mode: mode}
+		var got []string
+		for i := 0; i < 5; i++ {
+			row, ok := f.next()
+			if !ok {
+				break
+			}
+			got = append(got, row["k"])
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("mode %d: got %q; want %q", mode, got, want)
+		}
+	}
+
+	f := &Feeder{Rows: rows, Mode: Random}
+	for i := 0; i < 10; i++ {
+		if _, ok := f.next(); !ok {
+			t.Fatal("Random feeder ran out of rows")
+		}
+	}
+}
+
+func TestReadJSONL(t *testing.T) {
+	t.Parallel()
+
+	const in = `{"key":"abc","n":1}
+
+{"key":"def","n":2.5,"ok":true}
+`
+	rows, err := ReadJSONL(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("ReadJSONL() err=%q; want nil", err)
+	}
+	want := []Fields{
+		{"key": "abc", "n": "1"},
+		{"key": "def", "n": "2.5", "ok": "true"},
+	}
+	if !reflect.DeepEqual(rows, want) {
+		t.Errorf("\ngot  %v\nwant %v", rows, want)
+	}
+
+	if _, err := ReadJSONL(strings.NewReader("not json\n")); err == nil {
+		t.Error("ReadJSONL(bad json) err=nil; want err")
+	}
+}
@@ -0,0 +1,359 @@
+package hit
+
+import (
+	"fmt"
+	"html"
+	"html/template"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// HTML writes a self-contained HTML page with a summary and charts of
+// the latencies, RPS and status codes over the run's Intervals, and a
+// histogram of the latencies. The page needs no network to display.
+var HTML Reporter = ReporterFunc(func(w io.Writer, r *Result) error {
+	return htmlPage.Execute(w, newHTMLReport(r))
+})
+
+// htmlReport is the data of the HTML page.
+type htmlReport struct {
+	Summary    summary
+	Thresholds []ThresholdResult
+	Charts     []template.HTML
+}
+
+func newHTMLReport(r *Result) htmlReport {
+	report := htmlReport{
+		Summary:    newSummary(r),
+		Thresholds: r.Thresholds,
+	}
+	if len(r.Intervals) > 0 {
+		report.Charts = append(report.Charts,
+			latencyChart(r.Intervals),
+			rpsChart(r.Intervals),
+			statusChart(r.Intervals),
+		)
+	}
+	if len(r.latencies) > 0 {
+		report.Charts = append(report.Charts, histogram(r.latencies))
+	}
+	return report
+}
+
+func latencyChart(snapshots []Snapshot) template.HTML {
+	c := newTimeChart("Latency over time", "ms", snapshots)
+	for _, p := range []struct {
+		name, color string
+		d           func(Snapshot) time.Duration
+	}{
+		{"p50", "#4e79a7", func(s Snapshot) time.Duration { return s.P50 }},
+		{"p90", "#f28e2b", func(s Snapshot) time.Duration { return s.P90 }},
+		{"p99", "#e15759", func(s Snapshot) time.Duration { return s.P99 }},
+	} {
+		sr := series{name: p.name, color: p.color}
+		for _, s := range snapshots {
+			sr.ys = append(sr.ys, ms(p.d(s)))
+		}
+		c.series = append(c.series, sr)
+	}
+	return c.svg()
+}
+
+func rpsChart(snapshots []Snapshot) template.HTML {
+	c := newTimeChart("Requests per second", "rps", snapshots)
+	sr := series{name: "rps", color: "#4e79a7"}
+	for _, s := range snapshots {
+		sr.ys = append(sr.ys, s.RPS)
+	}
+	c.series = append(c.series, sr)
+	return c.svg()
+}
+
+// statusChart stacks the requests per second of each status code.
+func statusChart(snapshots []Snapshot) template.HTML {
+	c := newTimeChart("Status codes", "rps", snapshots)
+	c.stacked = true
+
+	seen := map[int]bool{}
+	for _, s := range snapshots {
+		for code := range s.Statuses {
+			seen[code] = true
+		}
+	}
+	codes := make([]int, 0, len(seen))
+	for code := range seen {
+		codes = append(codes, code)
+	}
+	sort.Ints(codes)
+
+	for _, code := range codes {
+		sr := series{name: strconv.Itoa(code), color: statusColor(code)}
+		if code == 0 {
+			sr.name = "error"
+		}
+		for _, s := range snapshots {
+			var rps float64
+			if s.Interval > 0 {
+				rps = float64(s.Statuses[code]) / s.Interval.Seconds()
+			}
+			sr.ys = append(sr.ys, rps)
+		}
+		c.series = append(c.series, sr)
+	}
+	return c.svg()
+}
+
+// statusColor returns the color of a status code's class.
+func statusColor(code int) string {
+	switch {
+	case code >= 500:
+		return "#e15759"
+	case code >= 400:
+		return "#f28e2b"
+	case code >= 300:
+		return "#4e79a7"
+	case code >= 200:
+		return "#59a14f"
+	}
+	return "#79706e"
+}
+
+// histogramBins is the number of bars of the latency histogram.
+const histogramBins = 40
+
+// histogram returns a bar chart of the number of requests by latency.
+func histogram(latencies []time.Duration) template.HTML {
+	lo, hi := latencies[0], latencies[0]
+	for _, d := range latencies {
+		if d < lo {
+			lo = d
+		}
+		if d > hi {
+			hi = d
+		}
+	}
+	width := (hi - lo) / histogramBins
+	if width <= 0 {
+		width = 1
+	}
+	counts := make([]float64, histogramBins)
+	for _, d := range latencies {
+		i := int((d - lo) / width)
+		if i >= histogramBins {
+			i = histogramBins - 1
+		}
+		counts[i]++
+	}
+
+	c := &chart{
+		title: "Latency histogram",
+		unit:  "requests",
+		xunit: "ms",
+		bars:  true,
+	}
+	for i := range counts {
+		c.xs = append(c.xs, ms(lo+time.Duration(i)*width))
+	}
+	c.xs = append(c.xs, ms(lo+histogramBins*width))
+	c.xmin = c.xs[0]
+	c.series = []series{{name: "requests", color: "#4e79a7", ys: counts}}
+	return c.svg()
+}
+
+// chart is an SVG chart of series of values.
+type chart struct {
+	title  string
+	unit   string    // unit is the unit of the values
+	xunit  string    // xunit is the unit of xs
+	xs     []float64 // xs are the x coordinates of the values
+	xmin   float64   // xmin is where the x axis starts
+	series []series
+
+	stacked bool // stacked stacks the series as areas
+	bars    bool // bars draws a bar from each x to the next
+}
+
+// series are the values of a chart's line, area or bars.
+type series struct {
+	name, color string
+	ys          []float64
+}
+
+// newTimeChart returns a chart with the elapsed seconds of the
+// snapshots as its x coordinates.
+func newTimeChart(title, unit string, snapshots []Snapshot) *chart {
+	c := &chart{title: title, unit: unit, xunit: "s"}
+	for _, s := range snapshots {
+		c.xs = append(c.xs, s.Elapsed.Seconds())
+	}
+	return c
+}
+
+// Chart dimensions.
+const (
+	chartWidth  = 720
+	chartHeight = 240
+	chartLeft   = 56
+	chartRight  = 16
+	chartTop    = 16
+	chartBottom = 32
+	chartTicks  = 4
+)
+
+// svg returns the chart as an SVG element followed by its legend.
+func (c *chart) svg() template.HTML {
+	if len(c.series) == 0 {
+		return template.HTML(fmt.Sprintf(`<figure><figcaption>%s</figcaption>No data</figure>`,
+			html.EscapeString(c.title)))
+	}
+	var (
+		b      strings.Builder
+		plotW  = float64(chartWidth - chartLeft - chartRight)
+		plotH  = float64(chartHeight - chartTop - chartBottom)
+		xmin   = c.xmin
+		xmax   = c.xs[len(c.xs)-1]
+		ymax   = c.max()
+		x0, y0 = float64(chartLeft), float64(chartTop) + plotH
+	)
+	if xmax <= xmin {
+		xmax = xmin + 1
+	}
+	if ymax <= 0 {
+		ymax = 1
+	}
+	px := func(x float64) float64 { return x0 + (x-xmin)/(xmax-xmin)*plotW }
+	py := func(y float64) float64 { return y0 - y/ymax*plotH }
+	p := func(format string, args ...any) { fmt.Fprintf(&b, format, args...) }
+
+	p(`<figure><figcaption>%s</figcaption>`, html.EscapeString(c.title))
+	p(`<svg xmlns="http://www.w3.org/2000/svg" viewBox="0 0 %d %d" width="%d" height="%d" font-size="11">`,
+		chartWidth, chartHeight, chartWidth, chartHeight)
+	for i := 0; i <= chartTicks; i++ {
+		v := ymax * float64(i) / chartTicks
+		y := py(v)
+		p(`<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#ddd"/>`, x0, y, x0+plotW, y)
+		p(`<text x="%.1f" y="%.1f" text-anchor="end">%s</text>`, x0-6, y+4, label(v))
+
+		v = xmin + (xmax-xmin)*float64(i)/chartTicks
+		p(`<text x="%.1f" y="%.1f" text-anchor="middle">%s%s</text>`,
+			px(v), y0+16, label(v), html.EscapeString(c.xunit))
+	}
+	p(`<text x="4" y="%d">%s</text>`, chartTop-4, html.EscapeString(c.unit))
+
+	base := make([]float64, len(c.series[0].ys))
+	for _, s := range c.series {
+		color := html.EscapeString(s.color)
+		switch {
+		case c.bars:
+			for i, y := range s.ys {
+				p(`<rect x="%.1f" y="%.1f" width="%.1f" height="%.1f" fill="%s"/>`,
+					px(c.xs[i]), py(y), px(c.xs[i+1])-px(c.xs[i]), y0-py(y), color)
+			}
+		case c.stacked:
+			var points []string
+			for i, y := range s.ys {
+				points = append(points, fmt.Sprintf("%.1f,%.1f", px(c.xs[i]), py(base[i]+y)))
+			}
+			for i := len(s.ys) - 1; i >= 0; i-- {
+				points = append(points, fmt.Sprintf("%.1f,%.1f", px(c.xs[i]), py(base[i])))
+				base[i] += s.ys[i]
+			}
+			p(`<polygon points="%s" fill="%s" fill-opacity="0.8"/>`, strings.Join(points, " "), color)
+		default:
+			var points []string
+			for i, y := range s.ys {
+				points = append(points, fmt.Sprintf("%.1f,%.1f", px(c.xs[i]), py(y)))
+			}
+			p(`<polyline points="%s" fill="none" stroke="%s" stroke-width="1.5"/>`, strings.Join(points, " "), color)
+		}
+	}
+	p(`<line x1="%.1f" y1="%.1f" x2="%.1f" y2="%.1f" stroke="#333"/>`, x0, y0, x0+plotW, y0)
+	p(`</svg><div class="legend">`)
+	for _, s := range c.series {
+		p(`<span><i style="background:%s"></i>%s</span>`, html.EscapeString(s.color), html.EscapeString(s.name))
+	}
+	p(`</div></figure>`)
+
+	// the chart escapes its own text
+	return template.HTML(b.String())
+}
+
+// max returns the largest value, or sum of values if the chart is
+// stacked, of the chart.
+func (c *chart) max() float64 {
+	var top float64
+	for i := range c.series[0].ys {
+		var sum float64
+		for _, s := range c.series {
+			if c.stacked {
+				sum += s.ys[i]
+			} else if s.ys[i] > top {
+				top = s.ys[i]
+			}
+		}
+		if sum > top {
+			top = sum
+		}
+	}
+	return top
+}
+
+// label formats an axis value with up to two decimals.
+func label(v float64) string {
+	prec := 2
+	switch {
+	case v >= 100:
+		prec = 0
+	case v >= 10:
+		prec = 1
+	}
+	s := strconv.FormatFloat(v, 'f', prec, 64)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	return s
+}
+
+var htmlPage = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>hit report</title>
+<style>
+body { font-family: sans-serif; margin: 2em; color: #333; }
+table { border-collapse: collapse; margin-bottom: 2em; }
+th, td { padding: 4px 12px; border-bottom: 1px solid #ddd; text-align: right; }
+th { text-align: left; }
+figure { margin: 0 0 2em; }
+figcaption { font-weight: bold; margin-bottom: .5em; }
+.legend span { margin-right: 1em; }
+.legend i { display: inline-block; width: 10px; height: 10px; margin-right: 4px; }
+.pass { color: #59a14f; }
+.fail { color: #e15759; }
+</style>
+</head>
+<body>
+<h1>hit report</h1>
+{{with .Summary}}<table>
+<tr><th>Requests</th><td>{{.Requests}}</td></tr>
+<tr><th>Errors</th><td>{{.Errors}}</td></tr>
+<tr><th>Success</th><td>{{printf "%.1f" .Success}}%</td></tr>
+<tr><th>RPS</th><td>{{printf "%.1f" .RPS}}</td></tr>
+<tr><th>Duration</th><td>{{printf "%.0f" .Duration}} ms</td></tr>
+<tr><th>Fastest</th><td>{{printf "%.2f" .Fastest}} ms</td></tr>
+<tr><th>Slowest</th><td>{{printf "%.2f" .Slowest}} ms</td></tr>
+<tr><th>P50</th><td>{{printf "%.2f" .P50}} ms</td></tr>
+<tr><th>P90</th><td>{{printf "%.2f" .P90}} ms</td></tr>
+<tr><th>P99</th><td>{{printf "%.2f" .P99}} ms</td></tr>
+</table>{{end}}
+{{with .Thresholds}}<h2>Thresholds</h2>
+<table>
+{{range .}}<tr><th>{{.Threshold}}</th><td>{{printf "%.2f" .Actual}}</td><td class="{{if .Pass}}pass{{else}}fail{{end}}">{{if .Pass}}PASS{{else}}FAIL{{end}}</td></tr>
+{{end}}</table>{{end}}
+{{range .Charts}}{{.}}
+{{end}}</body>
+</html>
+`))
@@ -0,0 +1,79 @@
+package hit
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReportHTML(t *testing.T) {
+	t.Parallel()
+
+	sum := newTestResult()
+	sum.Intervals = []Snapshot{
+		{Elapsed: time.Second, Interval: time.Second, Requests: 2, RPS: 2, P50: 10 * time.Millisecond,
+			Statuses: map[int]int{200: 2}},
+		{Elapsed: 2 * time.Second, Interval: time.Second, Requests: 2, Errors: 2, RPS: 2, P50: 30 * time.Millisecond,
+			Statuses: map[int]int{500: 1, 0: 1}},
+	}
+	th, err := ParseThreshold("p99<20ms")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum.Thresholds = []ThresholdResult{th.Eval(sum)}
+
+	var out bytes.Buffer
+	if err := HTML.Report(&out, sum); err != nil {
+		t.Fatalf("Report() err=%q; want nil", err)
+	}
+	page := out.String()
+
+	for _, want := range []string{
+		"<!DOCTYPE html>",
+		"Latency over time", "Requests per second", "Status codes", "Latency histogram",
+		"p99&lt;20ms", "FAIL",
+		">500</span>", ">error</span>",
+	} {
+		if !strings.Contains(page, want) {
+			t.Errorf("page does not contain %q", want)
+		}
+	}
+	if got := strings.Count(page, "<svg"); got != 4 {
+		t.Errorf("charts=%d; want 4", got)
+	}
+	for _, external := range []string{"<script", "<link", "src=", "https://"} {
+		if strings.Contains(page, external) {
+			t.Errorf("page contains %q; want a self-contained page", external)
+		}
+	}
+}
+
+func TestReportHTMLWithoutIntervals(t *testing.T) {
+	t.Parallel()
+
+	var sum Result
+	sum.Merge(&Result{Duration: time.Millisecond, Error: errors.New("refused")})
+	sum.Finalize(time.Second)
+
+	var out bytes.Buffer
+	if err := HTML.Report(&out, &sum); err != nil {
+		t.Fatalf("Report() err=%q; want nil", err)
+	}
+	if got := strings.Count(out.String(), "<svg"); got != 1 {
+		t.Errorf("charts=%d; want only the histogram", got)
+	}
+}
+
+func TestLabel(t *testing.T) {
+	t.Parallel()
+
+	for v, want := range map[float64]string{
+		0: "0", 0.126: "0.13", 1.5: "1.5", 12.34: "12.3", 250.4: "250", 1000: "1000",
+	} {
+		if got := label(v); got != want {
+			t.Errorf("label(%v)=%q; want %q", v, got, want)
+		}
+	}
+}
@@ -0,0 +1,159 @@
+package hit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Middleware wraps a SendFunc to extend what happens to each request,
+// such as to change the request before it is sent or to observe its
+// result.
+type Middleware func(SendFunc) SendFunc
+
+// Use adds middleware to the Client. See Client.Middleware.
+func Use(mws ...Middleware) Option {
+	return func(c *Client) { c.Middleware = append(c.Middleware, mws...) }
+}
+
+// Chain wraps fn with the middleware. The first middleware is the
+// outermost: it sees a request first and its result last.
+func Chain(fn SendFunc, mws ...Middleware) SendFunc {
+	for i := len(mws) - 1; i >= 0; i-- {
+		fn = mws[i](fn)
+	}
+	return fn
+}
+
+// Sign signs each request with sign before it is sent. A request that
+// sign fails to sign fails without being sent.
+func Sign(sign func(*http.Request) error) Middleware {
+	return func(next SendFunc) SendFunc {
+		return func(r *http.Request) *Result {
+			if err := sign(r); err != nil {
+				return fail(fmt.Errorf("sign: %w", err))(r)
+			}
+			return next(r)
+		}
+	}
+}
+
+// HMACSigner returns a function for Sign that sets the header to the
+// hex encoded HMAC-SHA256 of the request's method, URI, timestamp and
+// body with the key. The timestamp, in Unix seconds, is in the header
+// with a -Timestamp suffix, such as X-Signature-Timestamp.
+func HMACSigner(header string, key []byte) func(*http.Request) error {
+	return func(r *http.Request) error {
+		var body []byte
+		if r.GetBody != nil {
+			rc, err := r.GetBody()
+			if err != nil {
+				return err
+			}
+			body, err = io.ReadAll(rc)
+			rc.Close()
+			if err != nil {
+				return err
+			}
+		}
+		ts := strconv.FormatInt(time.Now().Unix(), 10)
+		mac := hmac.New(sha256.New, key)
+		fmt.Fprintf(mac, "%s\n%s\n%s\n", r.Method, r.URL.RequestURI(), ts)
+		mac.Write(body)
+
+		r.Header.Set(header, hex.EncodeToString(mac.Sum(nil)))
+		r.Header.Set(header+"-Timestamp", ts)
+		return nil
+	}
+}
+
+// Log logs each request's method, URL, status, duration and error.
+// The values that Interpolate interpolated are redacted.
+func Log(l *log.Logger) Middleware {
+	return func(next SendFunc) SendFunc {
+		return func(r *http.Request) *Result {
+			result := next(r)
+			msg := fmt.Sprintf("%s %s %d %s", r.Method, r.URL, result.Status, round(result.Duration))
+			if result.Error != nil {
+				msg += " " + result.Error.Error()
+			}
+			l.Print(Redact(msg))
+			return result
+		}
+	}
+}
+
+// Sampled applies the middleware to a random fraction of the requests,
+// from 0 to 1, such as to log one request out of a hundred.
+func Sampled(fraction float64, mw Middleware) Middleware {
+	return func(next SendFunc) SendFunc {
+		sampled := mw(next)
+		return func(r *http.Request) *Result {
+			if rand.Float64() < fraction {
+				return sampled(r)
+			}
+			return next(r)
+		}
+	}
+}
+
+// Metric records a custom metric with the value that fn returns for
+// each request and its result. The aggregated result's Metrics sum the
+// values.
+func Metric(name string, fn func(*http.Request, *Result) float64) Middleware {
+	return func(next SendFunc) SendFunc {
+		return func(r *http.Request) *Result {
+			result := next(r)
+			if result.Metrics == nil {
+				result.Metrics = make(map[string]float64)
+			}
+			result.Metrics[name] += fn(r, result)
+			return result
+		}
+	}
+}
+
+// exchanged carries the header and body of a response from retry back
+// out of the middleware, in the context of the request.
+type exchanged struct {
+	keep   bool
+	header http.Header
+	body   []byte
+}
+
+type exchangedKey struct{}
+
+// chain returns a function that sends requests with the Client's
+// middleware around retry. It keeps the header and body that retry
+// returns for the checks and the scenario's extractions.
+func (c *Client) chain(client *http.Client) exchangeFunc {
+	if len(c.Middleware) == 0 {
+		return func(r *http.Request, keep bool) (*Result, http.Header, []byte) {
+			return c.retry(client, r, keep)
+		}
+	}
+	send := Chain(func(r *http.Request) *Result {
+		x, ok := r.Context().Value(exchangedKey{}).(*exchanged)
+		if !ok {
+			// a middleware replaced the request's context
+			result, _, _ := c.retry(client, r, false)
+			return result
+		}
+		var result *Result
+		result, x.header, x.body = c.retry(client, r, x.keep)
+		return result
+	}, c.Middleware...)
+	return func(r *http.Request, keep bool) (*Result, http.Header, []byte) {
+		x := &exchanged{keep: keep}
+		result := send(r.WithContext(context.WithValue(r.Context(), exchangedKey{}, x)))
+		return result, x.header, x.body
+	}
+}
@@ -0,0 +1,157 @@
+package hit
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestChain(t *testing.T) {
+	t.Parallel()
+
+	var order []string
+	mw := func(name string) Middleware {
+		return func(next SendFunc) SendFunc {
+			return func(r *http.Request) *Result {
+				order = append(order, name+" in")
+				result := next(r)
+				order = append(order, name+" out")
+				return result
+			}
+		}
+	}
+	send := Chain(func(*http.Request) *Result {
+		order = append(order, "send")
+		return &Result{}
+	}, mw("a"), mw("b"))
+	send(newRequest(t, http.MethodGet, "/"))
+
+	want := "a in, b in, send, b out, a out"
+	if got := strings.Join(order, ", "); got != want {
+		t.Errorf("order=%q; want %q", got, want)
+	}
+}
+
+func TestClientMiddleware(t *testing.T) {
+	t.Parallel()
+
+	key := []byte("key")
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		mac := hmac.New(sha256.New, key)
+		mac.Write([]byte(r.Method + "\n" + r.URL.RequestURI() + "\n" + r.Header.Get("X-Signature-Timestamp") + "\n"))
+		mac.Write(body)
+		if r.Header.Get("X-Signature") != hex.EncodeToString(mac.Sum(nil)) {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+
+	var logs bytes.Buffer
+	client := &Client{C: 2}
+	Use(
+		Sign(HMACSigner("X-Signature", key)),
+		Log(log.New(&logs, "", 0)),
+		Metric("body", func(r *http.Request, result *Result) float64 {
+			return float64(r.ContentLength)
+		}),
+	)(client)
+
+	src := SourceFunc(func(ctx context.Context) *http.Request {
+		r, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/sign?q=1", strings.NewReader("hello"))
+		if err != nil {
+			t.Errorf("NewRequest err=%q; want nil", err)
+		}
+		return r
+	})
+	const n = 10
+	sum := client.Run(context.Background(), src, n)
+	if sum.Errors != 0 {
+		t.Fatalf("Errors=%d; want 0 with signed requests", sum.Errors)
+	}
+	if got := strings.Count(logs.String(), "POST "+server.URL+"/sign?q=1 200"); got != n {
+		t.Errorf("logged %d requests; want %d:\n%s", got, n, logs.String())
+	}
+	if got, want := sum.Metrics["body"], float64(n*len("hello")); got != want {
+		t.Errorf("Metrics[body]=%v; want %v", got, want)
+	}
+}
+
+func TestClientMiddlewareChecks(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("ok"))
+	})
+
+	var chained atomic.Int64
+	client := &Client{
+		C:      2,
+		Checks: []Check{BodyContains("ok")},
+		Middleware: []Middleware{func(next SendFunc) SendFunc {
+			chained.Add(1)
+			return next
+		}},
+	}
+	sum := client.Do(context.Background(), newRequest(t, http.MethodGet, server.URL), 10)
+	if sum.CheckFailures != 0 {
+		t.Errorf("CheckFailures=%d; want 0: the body passes through the middleware", sum.CheckFailures)
+	}
+	if got := chained.Load(); got != 2 {
+		t.Errorf("chained the middleware %d times; want once per virtual user, 2", got)
+	}
+}
+
+func TestSign(t *testing.T) {
+	t.Parallel()
+
+	sent := false
+	send := Sign(func(*http.Request) error {
+		return errors.New("no key")
+	})(func(*http.Request) *Result {
+		sent = true
+		return &Result{}
+	})
+	result := send(newRequest(t, http.MethodGet, "/"))
+	if sent {
+		t.Error("sent the request; want it to fail before it is sent")
+	}
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "sign: no key") {
+		t.Errorf("Error=%v; want the signing error", result.Error)
+	}
+}
+
+func TestSampled(t *testing.T) {
+	t.Parallel()
+
+	for _, tt := range []struct {
+		fraction float64
+		want     int
+	}{
+		{fraction: 0, want: 0},
+		{fraction: 1, want: 100},
+	} {
+		var n int
+		count := func(next SendFunc) SendFunc {
+			return func(r *http.Request) *Result {
+				n++
+				return next(r)
+			}
+		}
+		send := Sampled(tt.fraction, count)(func(*http.Request) *Result { return &Result{} })
+		for i := 0; i < 100; i++ {
+			send(newRequest(t, http.MethodGet, "/"))
+		}
+		if n != tt.want {
+			t.Errorf("Sampled(%v) applied %d times; want %d", tt.fraction, n, tt.want)
+		}
+	}
+}
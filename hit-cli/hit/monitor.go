@@ -0,0 +1,163 @@
+package hit
+
+import (
+	"fmt"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// Saturation is how loaded the Client itself was while it sent the
+// requests. Warnings explain how the Client, rather than the target,
+// may have been the bottleneck, in which case the result understates
+// what the target can take. ConcurrencyBound reports apart that the
+// workers were too few to keep up with the request rate at the target's
+// latency.
+type Saturation struct {
+	// Goroutines is the most goroutines that ran at once.
+	Goroutines int
+	// CPU is the process's CPU usage as a percentage of all the CPUs,
+	// or -1 if it is unknown on the platform.
+	CPU float64
+	// GCPause is how long the garbage collector stopped the process and
+	// GCs counts its cycles.
+	GCPause time.Duration
+	GCs     int
+	// SchedLag is the longest that a goroutine waited to run after its
+	// timer fired.
+	SchedLag time.Duration
+	// Missed counts the requests that the request rate fell behind on
+	// because the Client could not produce them in time.
+	Missed int
+	// Busy counts the requests that the request rate fell behind on
+	// because all the workers were busy waiting for the target.
+	Busy             int
+	ConcurrencyBound bool
+
+	Warnings []string
+}
+
+// Saturated reports whether the Client may have been the bottleneck.
+// Busy workers do not saturate the Client.
+func (s *Saturation) Saturated() bool { return s != nil && len(s.Warnings) > 0 }
+
+const (
+	// monitorInterval is how often the monitor samples the process.
+	monitorInterval = 100 * time.Millisecond
+	// minCPUWindow is the shortest run whose CPU usage is not too noisy
+	// to warn about.
+	minCPUWindow = time.Second
+
+	// The limits above which the Client warns that it was saturated.
+	maxCPU      = 90.0 // percent
+	maxGCPause  = 0.05 // fraction of the run
+	maxSchedLag = 20 * time.Millisecond
+	maxMissed   = 0.01 // fraction of the requests, missed or busy
+)
+
+// monitor samples the load of the process while a Client runs.
+type monitor struct {
+	start    time.Time
+	cpu      time.Duration
+	gc       runtime.MemStats
+	missed   atomic.Int64
+	busy     atomic.Int64
+	sampled  chan Saturation
+	stopping chan struct{}
+}
+
+// startMonitor starts sampling the process until stop.
+func startMonitor() *monitor {
+	m := &monitor{
+		start:    time.Now(),
+		cpu:      cpuTime(),
+		sampled:  make(chan Saturation),
+		stopping: make(chan struct{}),
+	}
+	runtime.ReadMemStats(&m.gc)
+	go m.sample()
+	return m
+}
+
+// sample the goroutines and the scheduling lag until stop.
+func (m *monitor) sample() {
+	var (
+		s    Saturation
+		t    = time.NewTimer(monitorInterval)
+		next = time.Now().Add(monitorInterval)
+	)
+	defer t.Stop()
+	for {
+		select {
+		case <-m.stopping:
+			if n := runtime.NumGoroutine(); n > s.Goroutines {
+				s.Goroutines = n
+			}
+			m.sampled <- s
+			return
+		case now := <-t.C:
+			if lag := time.Since(next); lag > s.SchedLag {
+				s.SchedLag = lag
+			}
+			if n := runtime.NumGoroutine(); n > s.Goroutines {
+				s.Goroutines = n
+			}
+			next = now.Add(monitorInterval)
+			t.Reset(monitorInterval)
+		}
+	}
+}
+
+// stop sampling and return the saturation of a run that sent the
+// requests.
+func (m *monitor) stop(requests int) *Saturation {
+	close(m.stopping)
+	s := <-m.sampled
+
+	elapsed := time.Since(m.start)
+	var gc runtime.MemStats
+	runtime.ReadMemStats(&gc)
+	s.GCPause = time.Duration(gc.PauseTotalNs - m.gc.PauseTotalNs)
+	s.GCs = int(gc.NumGC - m.gc.NumGC)
+	s.Missed = int(m.missed.Load())
+	s.Busy = int(m.busy.Load())
+	s.ConcurrencyBound = s.Busy > 0 && float64(s.Busy) > maxMissed*float64(requests)
+	s.CPU = -1
+	if cpu := cpuTime(); cpu >= 0 && elapsed > 0 {
+		s.CPU = 100 * float64(cpu-m.cpu) / float64(elapsed) / float64(runtime.NumCPU())
+	}
+
+	warn := func(format string, args ...any) {
+		s.Warnings = append(s.Warnings, fmt.Sprintf(format, args...))
+	}
+	if s.CPU > maxCPU && elapsed >= minCPUWindow {
+		warn("the client used %.0f%% of the CPUs", s.CPU)
+	}
+	if elapsed > 0 && float64(s.GCPause)/float64(elapsed) > maxGCPause {
+		warn("garbage collection paused the client for %s of %s", round(s.GCPause), round(elapsed))
+	}
+	if s.SchedLag > maxSchedLag {
+		warn("goroutines waited up to %s to run", round(s.SchedLag))
+	}
+	if s.Missed > 0 && float64(s.Missed) > maxMissed*float64(requests) {
+		warn("the client fell behind the request rate by %d requests", s.Missed)
+	}
+	return &s
+}
+
+// behind records that the request rate is behind by missed requests
+// and by busy requests while the workers were busy. The monitor keeps
+// the most of each.
+func (m *monitor) behind(missed, busy int) {
+	keepMost(&m.missed, missed)
+	keepMost(&m.busy, busy)
+}
+
+func keepMost(v *atomic.Int64, n int) {
+	for {
+		old := v.Load()
+		if int64(n) <= old || v.CompareAndSwap(old, int64(n)) {
+			return
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package hit
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestMonitor(t *testing.T) {
+	t.Parallel()
+
+	m := startMonitor()
+	time.Sleep(2 * monitorInterval)
+	m.behind(5, 0)
+	m.behind(3, 7)
+	s := m.stop(100)
+
+	if s.Goroutines == 0 {
+		t.Error("Goroutines=0; want the sampled goroutines")
+	}
+	if s.Missed != 5 || s.Busy != 7 {
+		t.Errorf("Missed=%d, Busy=%d; want the most, 5 and 7", s.Missed, s.Busy)
+	}
+	if !s.Saturated() || !strings.Contains(strings.Join(s.Warnings, "\n"), "by 5 requests") {
+		t.Errorf("Warnings=%q; want a warning about the missed requests", s.Warnings)
+	}
+	if !s.ConcurrencyBound {
+		t.Error("ConcurrencyBound=false; want true with busy workers")
+	}
+}
+
+func TestMonitorBusy(t *testing.T) {
+	t.Parallel()
+
+	m := startMonitor()
+	m.behind(0, 50)
+	s := m.stop(100)
+	if s.Saturated() {
+		t.Errorf("Warnings=%q; want none when only the workers were busy", s.Warnings)
+	}
+	if s.Busy != 50 || !s.ConcurrencyBound {
+		t.Errorf("Busy=%d, ConcurrencyBound=%t; want 50, true", s.Busy, s.ConcurrencyBound)
+	}
+}
+
+func TestThrottleBehind(t *testing.T) {
+	t.Parallel()
+
+	const n = 10
+	in := make(chan int, n)
+	for i := 0; i < n; i++ {
+		in <- i
+	}
+	close(in)
+
+	var missed, busy int
+	out := make(chan int)
+	go func() {
+		defer close(out)
+		throttleBehind(in, out, time.Millisecond, func(m, b int) {
+			missed, busy = m, b
+		})
+	}()
+	// receive slower than the throttle's rate, like busy workers
+	for range out {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if busy < n || missed > busy/10 {
+		t.Errorf("missed=%d, busy=%d; want busy at least %d and few missed", missed, busy, n)
+	}
+}
+
+func TestThrottleBehindMissed(t *testing.T) {
+	t.Parallel()
+
+	const n = 10
+	in := make(chan int)
+	go func() {
+		defer close(in)
+		// produce slower than the throttle's rate
+		for i := 0; i < n; i++ {
+			time.Sleep(5 * time.Millisecond)
+			in <- i
+		}
+	}()
+
+	var missed, busy int
+	out := make(chan int)
+	go func() {
+		defer close(out)
+		throttleBehind(in, out, time.Millisecond, func(m, b int) {
+			missed, busy = m, b
+		})
+	}()
+	for range out {
+	}
+	if missed < n || busy != 0 {
+		t.Errorf("missed=%d, busy=%d; want at least %d missed and none busy", missed, busy, n)
+	}
+}
+
+func TestClientSaturation(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t, func(http.ResponseWriter, *http.Request) {})
+	c := &Client{C: 1}
+	sum := c.Do(context.Background(), newRequest(t, http.MethodGet, server.URL), 10)
+	if sum.Saturation == nil || sum.Saturation.Goroutines == 0 {
+		t.Errorf("Saturation=%+v; want the client's load", sum.Saturation)
+	}
+}
+
+func TestClientConcurrencyBound(t *testing.T) {
+	t.Parallel()
+
+	// a worker sends at most 20 rps: the target, not the client, is slow
+	server := newTestServer(t, func(http.ResponseWriter, *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+	})
+	c := &Client{C: 1, RPS: 50}
+	sum := c.Do(context.Background(), newRequest(t, http.MethodGet, server.URL), 10)
+	if s := sum.Saturation; s.Saturated() || !s.ConcurrencyBound {
+		t.Errorf("Saturation=%+v; want concurrency-bound and not saturated", s)
+	}
+}
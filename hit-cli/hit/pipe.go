@@ -2,25 +2,52 @@ package hit
 
 import (
 	"context"
-	"net/http"
 	"sync"
 	"time"
 )
 
-// Produce calls fn n times and sends results to out.
-func Produce(ctx context.Context, out chan<- *http.Request, n int, fn func() *http.Request) {
+// The pipeline stages send values of any type from one stage to the
+// next through channels. The Client builds its HTTP pipeline out of
+// them, and they can build pipelines that load test anything else, such
+// as a database:
+//
+//	queries := make(chan string)
+//	go func() {
+//		defer close(queries)
+//		Produce(ctx, queries, 1000, func() (string, bool) { return "SELECT 1", true })
+//	}()
+//	durations := make(chan time.Duration)
+//	go func() {
+//		defer close(durations)
+//		Split(queries, durations, 10, func(q string) time.Duration {
+//			start := time.Now()
+//			db.ExecContext(ctx, q)
+//			return time.Since(start)
+//		})
+//	}()
+//	total := Merge(durations, time.Duration(0), func(sum, d time.Duration) time.Duration {
+//		return sum + d
+//	})
+
+// Produce calls fn n times, or until fn returns false, and sends the
+// values to out.
+func Produce[T any](ctx context.Context, out chan<- T, n int, fn func() (T, bool)) {
 	for ; n > 0; n-- {
+		v, ok := fn()
+		if !ok {
+			return
+		}
 		select {
 		case <-ctx.Done():
 			return
-		case out <- fn():
+		case out <- v:
 		}
 	}
 }
 
 // produce runs Produce in a goroutine.
-func produce(ctx context.Context, n int, fn func() *http.Request) <-chan *http.Request {
-	out := make(chan *http.Request)
+func produce[T any](ctx context.Context, n int, fn func() (T, bool)) <-chan T {
+	out := make(chan T)
 	go func() {
 		defer close(out)
 		Produce(ctx, out, n, fn)
@@ -30,52 +57,231 @@ func produce(ctx context.Context, n int, fn func() *http.Request) <-chan *http.R
 
 // Throttle slows down receiving from in by delay and
 // sends what it receives from in to out.
-func Throttle(in <-chan *http.Request, out chan<- *http.Request, delay time.Duration) {
+func Throttle[T any](in <-chan T, out chan<- T, delay time.Duration) {
+	throttleBehind(in, out, delay, nil)
+}
+
+// behindFunc is called with the number of values that a stage fell
+// behind its rate on: missed because it received them late from in or
+// ran late, and busy because out was not ready to receive them, such as
+// when all the workers of a Split are busy.
+type behindFunc func(missed, busy int)
+
+// throttleBehind is like Throttle, but if behind is not nil, it calls
+// behind with the number of values it fell behind its ticker on.
+func throttleBehind[T any](in <-chan T, out chan<- T, delay time.Duration, behind behindFunc) {
 	t := time.NewTicker(delay)
 	defer t.Stop()
 
-	for r := range in {
+	var (
+		start   = time.Now()
+		blocked time.Duration // blocked is how long out was not ready
+	)
+	for n := 1; ; n++ {
+		v, ok := <-in
+		if !ok {
+			return
+		}
 		<-t.C
-		out <- r
+		sent := time.Now()
+		out <- v
+		blocked += time.Since(sent)
+		if behind != nil {
+			behind(apportion(int(time.Since(start)/delay)-n, int(blocked/delay)))
+		}
 	}
 }
 
-// throttle runs Throttle in a goroutine.
-func throttle(in <-chan *http.Request, delay time.Duration) <-chan *http.Request {
-	out := make(chan *http.Request)
+// apportion splits the values that a stage fell behind on into those it
+// missed and those it fell behind on while out was busy, at most all.
+func apportion(behind, busy int) (missed, busyMissed int) {
+	if behind <= 0 {
+		return 0, 0
+	}
+	if busy > behind {
+		busy = behind
+	}
+	return behind - busy, busy
+}
+
+// throttle runs throttleBehind in a goroutine.
+func throttle[T any](in <-chan T, delay time.Duration, behind behindFunc) <-chan T {
+	out := make(chan T)
 	go func() {
 		defer close(out)
-		Throttle(in, out, delay)
+		throttleBehind(in, out, delay, behind)
 	}()
 	return out
 }
 
-// Split splits the pipeline into c goroutines, each running fn with
-// what Split receives from in, and sends results to out.
-func Split(in <-chan *http.Request, out chan<- *Result, c int, fn SendFunc) {
-	send := func() {
-		for r := range in {
-			out <- fn(r)
+// rampWait is the longest Ramp sleeps before checking the rate again.
+// It keeps Ramp responsive to rate changes and to the end of the stages.
+const rampWait = 10 * time.Millisecond
+
+// Ramp sends what it receives from in to out at the rate the stages
+// describe, and returns when the last stage ends.
+func Ramp[T any](in <-chan T, out chan<- T, stages Stages) {
+	rampBehind(in, out, stages, nil)
+}
+
+// rampBehind is like Ramp, but if behind is not nil, it calls behind
+// with the number of values it fell behind the rate on.
+func rampBehind[T any](in <-chan T, out chan<- T, stages Stages, behind behindFunc) {
+	var (
+		start   = time.Now()
+		end     = start.Add(stages.Duration())
+		last    = start
+		credits float64
+		missed  float64
+		busy    float64 // busy are the credits earned while out was not ready
+	)
+	for {
+		now := time.Now()
+		if !now.Before(end) {
+			return
+		}
+		rate := stages.Rate(now.Sub(start))
+		credits += rate * now.Sub(last).Seconds()
+		if limit := rate*rampWait.Seconds() + 1; credits > limit {
+			missed += credits - limit
+			credits = limit
+			if behind != nil {
+				behind(apportion(int(missed), int(busy)))
+			}
 		}
+		last = now
+
+		for ; credits >= 1; credits-- {
+			v, ok := <-in
+			if !ok {
+				return
+			}
+			sent := time.Now()
+			out <- v
+			busy += rate * time.Since(sent).Seconds()
+		}
+
+		wait := rampWait
+		if rate > 0 {
+			wait = time.Duration((1 - credits) / rate * float64(time.Second))
+		}
+		if wait > rampWait {
+			wait = rampWait
+		}
+		if left := time.Until(end); wait > left {
+			wait = left
+		}
+		time.Sleep(wait)
 	}
+}
 
+// ramp runs rampBehind in a goroutine.
+func ramp[T any](in <-chan T, stages Stages, behind behindFunc) <-chan T {
+	out := make(chan T)
+	go func() {
+		defer close(out)
+		rampBehind(in, out, stages, behind)
+	}()
+	return out
+}
+
+// Split splits the pipeline into c goroutines, each running fn with
+// what Split receives from in, and sends results to out.
+func Split[In, Out any](in <-chan In, out chan<- Out, c int, fn func(In) Out) {
+	splitEach(in, out, c, func(int) func(In) Out { return fn }, nil)
+}
+
+// splitEach is like Split, but each goroutine runs the function that fn
+// returns for the goroutine's number, from 1 to c. If wait is not nil,
+// each goroutine calls it with its number before it receives a value.
+func splitEach[In, Out any](in <-chan In, out chan<- Out, c int, fn func(id int) func(In) Out, wait func(id int)) {
 	var wg sync.WaitGroup
 	wg.Add(c)
-	for ; c > 0; c-- {
-		go func() {
+	for id := 1; id <= c; id++ {
+		go func(id int) {
 			defer wg.Done()
-			send()
-		}()
+			f := fn(id)
+			for {
+				if wait != nil {
+					wait(id)
+				}
+				v, ok := <-in
+				if !ok {
+					return
+				}
+				out <- f(v)
+			}
+		}(id)
 	}
 	wg.Wait()
 }
 
-// split runs Split in a goroutine
-func split(in <-chan *http.Request, c int, fn SendFunc) <-chan *Result {
-	out := make(chan *Result)
+// split runs splitEach in a goroutine
+func split[In, Out any](in <-chan In, c int, fn func(id int) func(In) Out, wait func(id int)) <-chan Out {
+	out := make(chan Out)
 	go func() {
 		defer close(out)
-		Split(in, out, c, fn)
+		splitEach(in, out, c, fn, wait)
 	}()
 	return out
 }
+
+// Merge merges what it receives from in into sum with fn, and returns
+// the sum when in is closed.
+func Merge[T, S any](in <-chan T, sum S, fn func(S, T) S) S {
+	for v := range in {
+		sum = fn(sum, v)
+	}
+	return sum
+}
+
+// Batch groups what it receives from in into batches of up to size
+// values and sends them to out. If wait is positive, it sends a smaller
+// batch when wait passes after the batch's first value. It sends the
+// last batch when in is closed.
+func Batch[T any](in <-chan T, out chan<- []T, size int, wait time.Duration) {
+	var (
+		batch   []T
+		timeout <-chan time.Time
+	)
+	flush := func() {
+		if len(batch) > 0 {
+			out <- batch
+		}
+		batch, timeout = nil, nil
+	}
+	for {
+		select {
+		case v, ok := <-in:
+			if !ok {
+				flush()
+				return
+			}
+			if len(batch) == 0 && wait > 0 {
+				timeout = time.After(wait)
+			}
+			batch = append(batch, v)
+			if len(batch) >= size {
+				flush()
+			}
+		case <-timeout:
+			flush()
+		}
+	}
+}
+
+// FanIn sends what it receives from each of ins to out, and returns
+// when all of ins are closed.
+func FanIn[T any](out chan<- T, ins ...<-chan T) {
+	var wg sync.WaitGroup
+	wg.Add(len(ins))
+	for _, in := range ins {
+		go func(in <-chan T) {
+			defer wg.Done()
+			for v := range in {
+				out <- v
+			}
+		}(in)
+	}
+	wg.Wait()
+}
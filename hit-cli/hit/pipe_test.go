@@ -0,0 +1,132 @@
+package hit
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestPipeline(t *testing.T) {
+	t.Parallel()
+
+	var i int
+	numbers := produce(context.Background(), 10, func() (int, bool) {
+		i++
+		return i, true
+	})
+	squares := split(numbers, 3, func(int) func(int) int {
+		return func(n int) int { return n * n }
+	}, nil)
+	if got, want := Merge(squares, 0, func(sum, n int) int { return sum + n }), 385; got != want {
+		t.Errorf("sum of squares=%d; want %d", got, want)
+	}
+}
+
+func TestProduceStops(t *testing.T) {
+	t.Parallel()
+
+	var i int
+	out := produce(context.Background(), 10, func() (int, bool) {
+		i++
+		return i, i <= 3
+	})
+	if got, want := collect(out), []int{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("produced %v; want %v", got, want)
+	}
+}
+
+func TestSplitSendFunc(t *testing.T) {
+	t.Parallel()
+
+	var send SendFunc = func(r *http.Request) *Result {
+		return &Result{Endpoint: r.URL.Path}
+	}
+	in := make(chan *http.Request, 1)
+	out := make(chan *Result, 1)
+	in <- newRequest(t, http.MethodGet, "/path")
+	close(in)
+	Split(in, out, 2, send)
+
+	if got := (<-out).Endpoint; got != "/path" {
+		t.Errorf("Endpoint=%q; want /path", got)
+	}
+}
+
+func TestBatch(t *testing.T) {
+	t.Parallel()
+
+	in := make(chan int)
+	out := make(chan []int)
+	go func() {
+		defer close(out)
+		Batch(in, out, 2, 0)
+	}()
+	go func() {
+		defer close(in)
+		for i := 1; i <= 5; i++ {
+			in <- i
+		}
+	}()
+	var got [][]int
+	for b := range out {
+		got = append(got, b)
+	}
+	if want := [][]int{{1, 2}, {3, 4}, {5}}; !reflect.DeepEqual(got, want) {
+		t.Errorf("batches=%v; want %v", got, want)
+	}
+}
+
+func TestBatchWait(t *testing.T) {
+	t.Parallel()
+
+	in := make(chan int)
+	out := make(chan []int)
+	go Batch(in, out, 10, time.Millisecond)
+	defer close(in)
+
+	in <- 1
+	select {
+	case b := <-out:
+		if want := []int{1}; !reflect.DeepEqual(b, want) {
+			t.Errorf("batch=%v; want %v", b, want)
+		}
+	case <-time.After(time.Second):
+		t.Error("Batch did not send the batch after waiting")
+	}
+}
+
+func TestFanIn(t *testing.T) {
+	t.Parallel()
+
+	a, b := make(chan int), make(chan int)
+	out := make(chan int)
+	go func() {
+		defer close(out)
+		FanIn(out, a, b)
+	}()
+	go func() {
+		defer close(a)
+		a <- 1
+		a <- 2
+	}()
+	go func() {
+		defer close(b)
+		b <- 3
+	}()
+	got := collect(out)
+	sort.Ints(got)
+	if want := []int{1, 2, 3}; !reflect.DeepEqual(got, want) {
+		t.Errorf("FanIn=%v; want %v", got, want)
+	}
+}
+
+func collect[T any](in <-chan T) []T {
+	var vs []T
+	for v := range in {
+		vs = append(vs, v)
+	}
+	return vs
+}
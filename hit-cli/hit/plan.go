@@ -0,0 +1,288 @@
+package hit
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+)
+
+// Plan is a load test described in a file that can live next to the
+// service it tests. LoadPlan reads it.
+type Plan struct {
+	Name string
+
+	// Requests is the number of requests, or scenario iterations, to
+	// send. With stages or a Duration, it only limits the run if it is
+	// positive.
+	Requests int
+	// Duration limits the run if it is positive. The run stops sending
+	// requests after Duration and lets the requests in flight finish.
+	Duration time.Duration
+
+	// Client sends the requests with the plan's concurrency, rate,
+	// stages, thresholds and checks.
+	Client *Client
+
+	// Targets are the templates of the requests to mix, unless the plan
+	// runs a Scenario. Feeder fills in their placeholders.
+	Targets  []Template
+	Scenario *Scenario
+	Feeder   *Feeder
+
+	// Outputs are where to report the result.
+	Outputs []Output
+}
+
+// Output is a report of a plan's result in an output that ValidOutput
+// accepts. An empty Path is the standard output.
+type Output struct {
+	Format string `json:"format"`
+	Path   string `json:"path,omitempty"`
+}
+
+// planFile is the JSON form of a plan. Durations are strings like 30s.
+type planFile struct {
+	Name        string      `json:"name"`
+	Requests    int         `json:"requests"`
+	Duration    string      `json:"duration"`
+	Concurrency int         `json:"concurrency"`
+	RPS         int         `json:"rps"`
+	Timeout     string      `json:"timeout"`
+	WarmUp      string      `json:"warm_up"`
+	Protocol    string      `json:"protocol"`
+	Stages      []planStage `json:"stages"`
+	StageMode   string      `json:"stage_mode"`
+	Targets     []Template  `json:"targets"`
+	Scenario    *Scenario   `json:"scenario"`
+	Feed        string      `json:"feed"`
+	FeedMode    string      `json:"feed_mode"`
+	Thresholds  []string    `json:"thresholds"`
+	Expect      *planExpect `json:"expect"`
+	Outputs     []Output    `json:"outputs"`
+}
+
+type planStage struct {
+	Duration string `json:"duration"`
+	Target   int    `json:"target"`
+}
+
+type planExpect struct {
+	Status []int  `json:"status"`
+	Body   string `json:"body"`
+}
+
+// defaultPlanRequests is the number of requests of a plan without
+// requests, stages or a duration.
+const defaultPlanRequests = 100
+
+// LoadPlan reads a plan from a JSON file. The plan's feed file and
+// output paths are relative to the plan's directory. For example:
+//
+//	{
+//		"name": "shortener",
+//		"concurrency": 10,
+//		"stages": [{"duration": "30s", "target": 100}, {"duration": "1m", "target": 100}],
+//		"targets": [{"url": "http://localhost:8080/r/{{key}}"}],
+//		"feed": "keys.csv",
+//		"thresholds": ["p99<200ms", "errors<1%"],
+//		"outputs": [{"format": "text"}, {"format": "html", "path": "report.html"}]
+//	}
+func LoadPlan(path string) (*Plan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var pf planFile
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.DisallowUnknownFields()
+	if err := d.Decode(&pf); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	p, err := pf.plan(filepath.Dir(path))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return p, nil
+}
+
+// plan returns the plan the file describes. dir is the directory of
+// the file.
+func (pf *planFile) plan(dir string) (*Plan, error) {
+	p := &Plan{
+		Name:     pf.Name,
+		Requests: pf.Requests,
+		Targets:  pf.Targets,
+		Scenario: pf.Scenario,
+		Outputs:  pf.Outputs,
+		Client: &Client{
+			C:       pf.Concurrency,
+			RPS:     pf.RPS,
+			Timeout: 10 * time.Second,
+		},
+	}
+	var err error
+	switch {
+	case pf.Requests < 0:
+		return nil, errors.New("requests: must be positive")
+	case pf.Concurrency < 0:
+		return nil, errors.New("concurrency: must be positive")
+	case pf.RPS < 0:
+		return nil, errors.New("rps: must be positive")
+	case (len(pf.Targets) > 0) == (pf.Scenario != nil):
+		return nil, errors.New("set either targets or a scenario")
+	case len(pf.Stages) > 0 && pf.RPS > 0:
+		return nil, errors.New("rps: cannot be used with stages")
+	}
+	if p.Duration, err = parseDuration("duration", pf.Duration); err != nil {
+		return nil, err
+	}
+	if pf.Timeout != "" {
+		if p.Client.Timeout, err = parseDuration("timeout", pf.Timeout); err != nil {
+			return nil, err
+		}
+	}
+	if pf.WarmUp != "" {
+		if p.Client.WarmUp, p.Client.WarmUpDuration, err = ParseWarmUp(pf.WarmUp); err != nil {
+			return nil, fmt.Errorf("warm_up: %w", err)
+		}
+	}
+	if pf.Protocol != "" {
+		if p.Client.Protocol, err = ParseProtocol(pf.Protocol); err != nil {
+			return nil, fmt.Errorf("protocol: %w", err)
+		}
+	}
+	for i, st := range pf.Stages {
+		d, err := parseDuration(fmt.Sprintf("stage %d", i+1), st.Duration)
+		if err != nil {
+			return nil, err
+		}
+		if d <= 0 || st.Target < 0 {
+			return nil, fmt.Errorf("stage %d: want a positive duration and target", i+1)
+		}
+		p.Client.Stages = append(p.Client.Stages, Stage{Duration: d, Target: st.Target})
+	}
+	if pf.StageMode != "" {
+		if p.Client.StageMode, err = ParseStageMode(pf.StageMode); err != nil {
+			return nil, fmt.Errorf("stage_mode: %w", err)
+		}
+		if len(p.Client.Stages) == 0 {
+			return nil, errors.New("stage_mode: requires stages")
+		}
+	}
+	if p.Client.StageMode == StageConcurrency {
+		if pf.Concurrency > 0 {
+			return nil, errors.New("concurrency: cannot be used with the concurrency stage mode")
+		}
+		p.Client.C = p.Client.Stages.Max()
+	}
+	if p.Client.C == 0 {
+		p.Client.C = runtime.NumCPU()
+	}
+	if p.Requests == 0 && len(p.Client.Stages) == 0 && p.Duration == 0 {
+		p.Requests = defaultPlanRequests
+	}
+	for _, expr := range pf.Thresholds {
+		th, err := ParseThreshold(expr)
+		if err != nil {
+			return nil, fmt.Errorf("thresholds: %w", err)
+		}
+		p.Client.Thresholds = append(p.Client.Thresholds, th)
+	}
+	if e := pf.Expect; e != nil {
+		if len(e.Status) > 0 {
+			p.Client.Checks = append(p.Client.Checks, StatusIn(e.Status...))
+		}
+		if e.Body != "" {
+			p.Client.Checks = append(p.Client.Checks, BodyContains(e.Body))
+		}
+	}
+	for i, o := range p.Outputs {
+		if err := ValidOutput(o.Format); err != nil {
+			return nil, fmt.Errorf("outputs: %w", err)
+		}
+		if o.Path != "" && !filepath.IsAbs(o.Path) {
+			p.Outputs[i].Path = filepath.Join(dir, o.Path)
+		}
+	}
+	if len(p.Targets) > 0 {
+		if _, err := Mix(p.Targets...); err != nil {
+			return nil, fmt.Errorf("targets: %w", err)
+		}
+	}
+	if pf.Feed != "" {
+		if p.Feeder, err = pf.feeder(dir); err != nil {
+			return nil, err
+		}
+	}
+	if p.Scenario != nil {
+		if err := p.Scenario.init(); err != nil {
+			return nil, fmt.Errorf("scenario: %w", err)
+		}
+	}
+	return p, nil
+}
+
+// feeder loads the feed relative to dir.
+func (pf *planFile) feeder(dir string) (*Feeder, error) {
+	path := pf.Feed
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(dir, path)
+	}
+	rows, err := LoadFeed(path)
+	if err != nil {
+		return nil, fmt.Errorf("feed: %w", err)
+	}
+	f := &Feeder{Rows: rows}
+	if pf.FeedMode != "" {
+		if f.Mode, err = ParseFeedMode(pf.FeedMode); err != nil {
+			return nil, fmt.Errorf("feed_mode: %w", err)
+		}
+	}
+	return f, nil
+}
+
+// parseDuration parses the duration of a plan's field. An empty
+// duration is zero.
+func parseDuration(field, s string) (time.Duration, error) {
+	if s == "" {
+		return 0, nil
+	}
+	d, err := time.ParseDuration(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", field, err)
+	}
+	return d, nil
+}
+
+// Run the plan and return the aggregated result.
+func (p *Plan) Run(ctx context.Context) (*Result, error) {
+	var (
+		src  Source
+		send = p.Client.send
+		err  error
+	)
+	if p.Scenario != nil {
+		p.Scenario.Feeder = p.Feeder
+		src, send, err = p.Client.scenario(p.Scenario)
+	} else {
+		src, err = Feed(p.Feeder, p.Targets...)
+	}
+	if err != nil {
+		return nil, err
+	}
+	n := p.Requests
+	if p.Duration > 0 {
+		src = until(time.Now().Add(p.Duration), src)
+		if n <= 0 {
+			n = math.MaxInt
+		}
+	}
+	return p.Client.run(ctx, src, n, send), nil
+}
@@ -0,0 +1,165 @@
+package hit
+
+import (
+	"context"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// writePlan writes a plan file and a feed next to it, and returns the
+// plan's path.
+func writePlan(t *testing.T, plan string) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "keys.csv"), []byte("key\na\nb\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "plan.json")
+	if err := os.WriteFile(path, []byte(plan), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadPlan(t *testing.T) {
+	t.Parallel()
+
+	var hits atomic.Int64
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/a" || r.URL.Path == "/b" {
+			hits.Add(1)
+		}
+	})
+	path := writePlan(t, `{
+		"name": "keys",
+		"requests": 10,
+		"concurrency": 2,
+		"timeout": "2s",
+		"targets": [{"url": "`+server.URL+`/{{key}}"}],
+		"feed": "keys.csv",
+		"feed_mode": "circular",
+		"thresholds": ["p99<1s", "errors<1%"],
+		"expect": {"status": [200]},
+		"outputs": [{"format": "json", "path": "summary.json"}]
+	}`)
+
+	p, err := LoadPlan(path)
+	if err != nil {
+		t.Fatalf("LoadPlan() err=%q; want nil", err)
+	}
+	if p.Name != "keys" || p.Requests != 10 || p.Client.C != 2 || p.Client.Timeout != 2*time.Second {
+		t.Errorf("LoadPlan()=%+v; want the plan's settings", p)
+	}
+	if got := len(p.Client.Thresholds); got != 2 {
+		t.Errorf("Thresholds=%d; want 2", got)
+	}
+	if got := len(p.Client.Checks); got != 1 {
+		t.Errorf("Checks=%d; want 1", got)
+	}
+	if p.Feeder == nil || len(p.Feeder.Rows) != 2 {
+		t.Fatalf("Feeder=%+v; want the 2 rows of the feed next to the plan", p.Feeder)
+	}
+	if got, want := p.Outputs[0].Path, filepath.Join(filepath.Dir(path), "summary.json"); got != want {
+		t.Errorf("Outputs[0].Path=%q; want %q, next to the plan", got, want)
+	}
+
+	sum, err := p.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() err=%q; want nil", err)
+	}
+	if sum.Requests != 10 || hits.Load() != 10 {
+		t.Errorf("Requests=%d, hits=%d; want 10", sum.Requests, hits.Load())
+	}
+	if failed := sum.Failed(); len(failed) > 0 {
+		t.Errorf("Failed()=%v; want none", failed)
+	}
+}
+
+func TestLoadPlanStages(t *testing.T) {
+	t.Parallel()
+
+	path := writePlan(t, `{
+		"stages": [{"duration": "30s", "target": 10}, {"duration": "1m", "target": 10}],
+		"scenario": {"steps": [{"url": "http://go.dev"}]}
+	}`)
+	p, err := LoadPlan(path)
+	if err != nil {
+		t.Fatalf("LoadPlan() err=%q; want nil", err)
+	}
+	if got := p.Client.Stages.Duration(); got != 90*time.Second {
+		t.Errorf("Stages.Duration()=%s; want 1m30s", got)
+	}
+	if p.Requests != 0 {
+		t.Errorf("Requests=%d; want 0 to run until the stages end", p.Requests)
+	}
+}
+
+func TestPlanRunDuration(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t, func(http.ResponseWriter, *http.Request) {
+		time.Sleep(50 * time.Millisecond)
+	})
+	p, err := LoadPlan(writePlan(t, `{
+		"duration": "300ms",
+		"concurrency": 2,
+		"targets": [{"url": "`+server.URL+`"}]
+	}`))
+	if err != nil {
+		t.Fatalf("LoadPlan() err=%q; want nil", err)
+	}
+	sum, err := p.Run(context.Background())
+	if err != nil {
+		t.Fatalf("Run() err=%q; want nil", err)
+	}
+	if p.Requests != 0 {
+		t.Errorf("Requests=%d; want 0 to run until the duration ends", p.Requests)
+	}
+	if sum.Requests == 0 || sum.Requests > 20 {
+		t.Errorf("Requests=%d; want the duration to end the run", sum.Requests)
+	}
+	if sum.Errors != 0 {
+		t.Errorf("Errors=%d; want 0: the requests in flight finish", sum.Errors)
+	}
+}
+
+func TestLoadPlanError(t *testing.T) {
+	t.Parallel()
+
+	const target = `"targets": [{"url": "http://go.dev"}]`
+	tests := map[string]string{
+		"json":       `{`,
+		"unknown":    `{"url": "http://go.dev"}`,
+		"nothing":    `{}`,
+		"both":       `{` + target + `, "scenario": {"steps": [{"url": "http://go.dev"}]}}`,
+		"requests":   `{` + target + `, "requests": -1}`,
+		"rps":        `{` + target + `, "rps": 1, "stages": [{"duration": "1s", "target": 1}]}`,
+		"stage":      `{` + target + `, "stages": [{"duration": "soon", "target": 1}]}`,
+		"timeout":    `{` + target + `, "timeout": "10"}`,
+		"threshold":  `{` + target + `, "thresholds": ["p99<fast"]}`,
+		"output":     `{` + target + `, "outputs": [{"format": "xml"}]}`,
+		"feed":       `{` + target + `, "feed": "missing.csv"}`,
+		"feed mode":  `{` + target + `, "feed": "keys.csv", "feed_mode": "shuffled"}`,
+		"target":     `{"targets": [{"url": "http://go.dev", "weight": -1}]}`,
+		"protocol":   `{` + target + `, "protocol": "h3"}`,
+		"warm up":    `{` + target + `, "warm_up": "soon"}`,
+		"stage mode": `{` + target + `, "stage_mode": "vus", "stages": [{"duration": "1s", "target": 1}]}`,
+		"no stages":  `{` + target + `, "stage_mode": "concurrency"}`,
+		"workers":    `{` + target + `, "stage_mode": "concurrency", "concurrency": 2, "stages": [{"duration": "1s", "target": 1}]}`,
+	}
+	for name, plan := range tests {
+		plan := plan
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := LoadPlan(writePlan(t, plan)); err == nil {
+				t.Errorf("LoadPlan(%s) err=nil; want an error", plan)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package hit
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+
+	"golang.org/x/net/http2"
+)
+
+// Protocol is the HTTP protocol a Client sends requests with.
+type Protocol string
+
+// Protocols.
+const (
+	HTTP1 Protocol = "http1" // HTTP1 is HTTP/1.1, the default
+	HTTP2 Protocol = "h2"    // HTTP2 is HTTP/2 over TLS
+	H2C   Protocol = "h2c"   // H2C is cleartext HTTP/2 with prior knowledge
+)
+
+// ParseProtocol converts a protocol name to a Protocol.
+func ParseProtocol(s string) (Protocol, error) {
+	switch p := Protocol(s); p {
+	case HTTP1, HTTP2, H2C:
+		return p, nil
+	}
+	return "", fmt.Errorf("unknown protocol %q", s)
+}
+
+// h2Pool is an HTTP/2 round tripper that spreads the streams over
+// connections, keeping at most max concurrent streams on each.
+type h2Pool struct {
+	mu    sync.Mutex
+	conns []*h2Conn
+}
+
+// h2Conn is an HTTP/2 transport that keeps a single connection.
+type h2Conn struct {
+	*http2.Transport
+	streams int
+}
+
+// newH2Pool returns a pool of enough connections to send c concurrent
+// streams with at most max streams per connection. Zero max sends all
+// the streams over a connection.
+func newH2Pool(p Protocol, cfg *tls.Config, c, max int) *h2Pool {
+	n := 1
+	if max > 0 {
+		n = (c + max - 1) / max
+	}
+	pool := &h2Pool{conns: make([]*h2Conn, n)}
+	for i := range pool.conns {
+		t := &http2.Transport{
+			TLSClientConfig:            cfg,
+			StrictMaxConcurrentStreams: true,
+		}
+		if p == H2C {
+			t.AllowHTTP = true
+			t.DialTLSContext = func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, addr)
+			}
+		}
+		pool.conns[i] = &h2Conn{Transport: t}
+	}
+	return pool
+}
+
+// RoundTrip sends the request over the connection with the fewest
+// streams. The stream ends when the response body is closed.
+func (p *h2Pool) RoundTrip(r *http.Request) (*http.Response, error) {
+	conn := p.acquire()
+	response, err := conn.RoundTrip(r)
+	if err != nil {
+		p.release(conn)
+		return nil, err
+	}
+	response.Body = &stream{ReadCloser: response.Body, end: func() { p.release(conn) }}
+	return response, nil
+}
+
+// CloseIdleConnections closes the pool's idle connections.
+func (p *h2Pool) CloseIdleConnections() {
+	for _, conn := range p.conns {
+		conn.CloseIdleConnections()
+	}
+}
+
+func (p *h2Pool) acquire() *h2Conn {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	conn := p.conns[0]
+	for _, c := range p.conns[1:] {
+		if c.streams < conn.streams {
+			conn = c
+		}
+	}
+	conn.streams++
+	return conn
+}
+
+func (p *h2Pool) release(conn *h2Conn) {
+	p.mu.Lock()
+	conn.streams--
+	p.mu.Unlock()
+}
+
+// stream is a response body that calls end once when it's closed.
+type stream struct {
+	io.ReadCloser
+	once sync.Once
+	end  func()
+}
+
+func (s *stream) Close() error {
+	err := s.ReadCloser.Close()
+	s.once.Do(s.end)
+	return err
+}
@@ -0,0 +1,78 @@
+package hit
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+func TestClientTransportHTTP1(t *testing.T) {
+	t.Parallel()
+
+	// without a TLS config, a transport negotiates HTTP/2 over TLS
+	// unless its TLSNextProto is non-nil
+	for _, c := range []*Client{{}, {Protocol: HTTP1}} {
+		tr, ok := c.transport().(*http.Transport)
+		if !ok || tr.TLSNextProto == nil {
+			t.Errorf("Protocol %q: transport=%T; want an HTTP/1.1 transport without TLSNextProto", c.Protocol, c.transport())
+		}
+	}
+}
+
+func TestClientDoHTTP2(t *testing.T) {
+	t.Parallel()
+
+	const hits = 20
+
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+	})
+
+	h2 := httptest.NewUnstartedServer(handler)
+	h2.EnableHTTP2 = true
+	h2.StartTLS()
+	t.Cleanup(h2.Close)
+
+	cleartext := newTestServer(t, h2c.NewHandler(handler, &http2.Server{}).ServeHTTP)
+
+	tests := map[string]struct {
+		url        string
+		proto      Protocol
+		maxStreams int
+		wantConns  int
+	}{
+		"h2":              {h2.URL, HTTP2, 0, 1},
+		"h2/max-streams":  {h2.URL, HTTP2, 2, 2},
+		"h2c":             {cleartext.URL, H2C, 0, 1},
+		"h2c/max-streams": {cleartext.URL, H2C, 1, 4},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			cfg, _ := TLSOptions{Insecure: true}.Config()
+			c := &Client{
+				C:          4,
+				TLS:        cfg,
+				Protocol:   tt.proto,
+				MaxStreams: tt.maxStreams,
+			}
+			sum := c.Do(context.Background(), newRequest(t, http.MethodGet, tt.url), hits)
+			if sum.Errors != 0 {
+				t.Fatalf("Errors=%d; want 0", sum.Errors)
+			}
+			if sum.Streams != hits {
+				t.Errorf("Streams=%d; want %d", sum.Streams, hits)
+			}
+			if sum.Conns != tt.wantConns {
+				t.Errorf("Conns=%d; want %d", sum.Conns, tt.wantConns)
+			}
+		})
+	}
+}
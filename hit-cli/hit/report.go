@@ -0,0 +1,322 @@
+package hit
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Reporter writes a summary of an aggregated result.
+type Reporter interface {
+	Report(w io.Writer, r *Result) error
+}
+
+// ReporterFunc is an adapter to use a function as a Reporter.
+type ReporterFunc func(w io.Writer, r *Result) error
+
+// Report calls f(w, r).
+func (f ReporterFunc) Report(w io.Writer, r *Result) error { return f(w, r) }
+
+// Summary reporters.
+var (
+	// Text writes a human readable summary.
+	Text Reporter = ReporterFunc(func(w io.Writer, r *Result) error {
+		r.Fprint(w)
+		return nil
+	})
+	// JSON writes a summary as a JSON object with a sample of the
+	// latencies. ReadSummary reads it back.
+	JSON Reporter = ReporterFunc(func(w io.Writer, r *Result) error {
+		s := newSummary(r)
+		for _, d := range sampleLatencies(r.latencies, maxLatencySample) {
+			s.Latencies = append(s.Latencies, ms(d))
+		}
+		e := json.NewEncoder(w)
+		e.SetIndent("", "  ")
+		return e.Encode(s)
+	})
+	// CSV writes a summary as a CSV header and a row for all the
+	// requests, followed by a row for each endpoint.
+	CSV Reporter = ReporterFunc(func(w io.Writer, r *Result) error {
+		cw := csv.NewWriter(w)
+		_ = cw.Write(summaryHeader)
+		_ = cw.Write(append([]string{"all"}, newSummary(r).row()...))
+		for _, e := range r.sortedEndpoints() {
+			_ = cw.Write(append([]string{e.Endpoint}, newSummary(e).row()...))
+		}
+		cw.Flush()
+		return cw.Error()
+	})
+)
+
+// summary is the machine readable form of an aggregated result.
+// Durations are in milliseconds.
+type summary struct {
+	Requests int     `json:"requests"`
+	Errors   int     `json:"errors"`
+	Success  float64 `json:"success"`
+	RPS      float64 `json:"rps"`
+	Bytes    int64   `json:"bytes"`
+	Duration float64 `json:"duration_ms"`
+	Fastest  float64 `json:"fastest_ms"`
+	Slowest  float64 `json:"slowest_ms"`
+	P50      float64 `json:"p50_ms"`
+	P90      float64 `json:"p90_ms"`
+	P99      float64 `json:"p99_ms"`
+	DNS      float64 `json:"dns_ms"`
+	Connect  float64 `json:"connect_ms"`
+	TLS      float64 `json:"tls_ms"`
+	TTFB     float64 `json:"ttfb_ms"`
+	Transfer float64 `json:"transfer_ms"`
+	Reused   int     `json:"reused"`
+	Conns    int     `json:"conns"`
+	Streams  int     `json:"streams"`
+	Checks   int     `json:"check_failures"`
+	Retries  int     `json:"retries"`
+	First    float64 `json:"first_success"`
+
+	Metrics    map[string]float64 `json:"metrics,omitempty"`
+	Samples    []sample           `json:"samples,omitempty"`
+	Endpoints  map[string]summary `json:"endpoints,omitempty"`
+	Thresholds []threshold        `json:"thresholds,omitempty"`
+	WarmUp     *summary           `json:"warm_up,omitempty"`
+	Saturation *saturation        `json:"saturation,omitempty"`
+
+	// Latencies are sampled to compare the summary with another.
+	Latencies []float64 `json:"latencies_ms,omitempty"`
+}
+
+// sample is the machine readable form of a response that failed a check.
+type sample struct {
+	Endpoint string      `json:"endpoint"`
+	Status   int         `json:"status"`
+	Header   http.Header `json:"header,omitempty"`
+	Body     string      `json:"body"`
+	Reason   string      `json:"reason"`
+}
+
+func newSample(s Sample) sample {
+	return sample{
+		Endpoint: s.Endpoint,
+		Status:   s.Status,
+		Header:   s.Header,
+		Body:     string(s.Body),
+		Reason:   s.Reason,
+	}
+}
+
+// saturation is the machine readable form of the Client's saturation.
+type saturation struct {
+	Goroutines int      `json:"goroutines"`
+	CPU        float64  `json:"cpu_percent"`
+	GCPause    float64  `json:"gc_pause_ms"`
+	GCs        int      `json:"gcs"`
+	SchedLag   float64  `json:"sched_lag_ms"`
+	Missed     int      `json:"missed"`
+	Busy       int      `json:"busy"`
+	Bound      bool     `json:"concurrency_bound"`
+	Warnings   []string `json:"warnings,omitempty"`
+}
+
+// threshold is the machine readable form of an evaluated threshold.
+type threshold struct {
+	Threshold string  `json:"threshold"`
+	Actual    float64 `json:"actual"`
+	Pass      bool    `json:"pass"`
+}
+
+var summaryHeader = []string{
+	"endpoint", "requests", "errors", "success", "rps", "bytes", "duration_ms",
+	"fastest_ms", "slowest_ms", "p50_ms", "p90_ms", "p99_ms",
+	"dns_ms", "connect_ms", "tls_ms", "ttfb_ms", "transfer_ms", "reused",
+	"conns", "streams", "check_failures", "retries", "first_success",
+}
+
+func newSummary(r *Result) summary {
+	s := summary{
+		Requests: r.Requests,
+		Errors:   r.Errors,
+		Success:  r.success(),
+		RPS:      r.RPS,
+		Bytes:    r.Bytes,
+		Duration: ms(r.Duration),
+		Fastest:  ms(r.Fastest),
+		Slowest:  ms(r.Slowest),
+		P50:      ms(r.P50),
+		P90:      ms(r.P90),
+		P99:      ms(r.P99),
+		DNS:      ms(r.Phases.DNS),
+		Connect:  ms(r.Phases.Connect),
+		TLS:      ms(r.Phases.TLS),
+		TTFB:     ms(r.Phases.TTFB),
+		Transfer: ms(r.Phases.Transfer),
+		Reused:   r.Reused,
+		Conns:    r.Conns,
+		Streams:  r.Streams,
+		Checks:   r.CheckFailures,
+		Retries:  r.Retries,
+		First:    r.firstSuccess(),
+		Metrics:  r.Metrics,
+	}
+	for _, smp := range r.Samples {
+		s.Samples = append(s.Samples, newSample(smp))
+	}
+	for _, t := range r.Thresholds {
+		s.Thresholds = append(s.Thresholds, threshold{
+			Threshold: t.Threshold.String(),
+			Actual:    t.Actual,
+			Pass:      t.Pass,
+		})
+	}
+	for name, e := range r.Endpoints {
+		if s.Endpoints == nil {
+			s.Endpoints = make(map[string]summary, len(r.Endpoints))
+		}
+		s.Endpoints[name] = newSummary(e)
+	}
+	if r.WarmUp != nil {
+		w := newSummary(r.WarmUp)
+		s.WarmUp = &w
+	}
+	if sat := r.Saturation; sat != nil {
+		s.Saturation = &saturation{
+			Goroutines: sat.Goroutines,
+			CPU:        sat.CPU,
+			GCPause:    ms(sat.GCPause),
+			GCs:        sat.GCs,
+			SchedLag:   ms(sat.SchedLag),
+			Missed:     sat.Missed,
+			Busy:       sat.Busy,
+			Bound:      sat.ConcurrencyBound,
+			Warnings:   sat.Warnings,
+		}
+	}
+	return s
+}
+
+// row returns the summary's fields in the order of summaryHeader
+// without the endpoint.
+func (s summary) row() []string {
+	f := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	return []string{
+		strconv.Itoa(s.Requests), strconv.Itoa(s.Errors), f(s.Success),
+		f(s.RPS), strconv.FormatInt(s.Bytes, 10), f(s.Duration),
+		f(s.Fastest), f(s.Slowest), f(s.P50), f(s.P90), f(s.P99),
+		f(s.DNS), f(s.Connect), f(s.TLS), f(s.TTFB), f(s.Transfer),
+		strconv.Itoa(s.Reused), strconv.Itoa(s.Conns), strconv.Itoa(s.Streams),
+		strconv.Itoa(s.Checks), strconv.Itoa(s.Retries), f(s.First),
+	}
+}
+
+// NDJSON returns a function that writes each request's result to w as
+// a line of JSON. Use it as the Client's OnResult function.
+func NDJSON(w io.Writer) func(*Result) {
+	e := json.NewEncoder(w)
+	return func(r *Result) {
+		_ = e.Encode(newRecord(r))
+	}
+}
+
+// record is the machine readable form of a request's result.
+type record struct {
+	Time     string   `json:"time,omitempty"`
+	Duration float64  `json:"duration_ms"`
+	Status   int      `json:"status"`
+	Bytes    int64    `json:"bytes"`
+	Error    string   `json:"error,omitempty"`
+	Class    string   `json:"class,omitempty"`
+	DNS      float64  `json:"dns_ms"`
+	Connect  float64  `json:"connect_ms"`
+	TLS      float64  `json:"tls_ms"`
+	TTFB     float64  `json:"ttfb_ms"`
+	Transfer float64  `json:"transfer_ms"`
+	NewConn  bool     `json:"new_conn,omitempty"`
+	Reused   bool     `json:"reused"`
+	Proto    string   `json:"proto,omitempty"`
+	Endpoint string   `json:"endpoint,omitempty"`
+	Check    string   `json:"check,omitempty"`
+	StatusOK *bool    `json:"status_ok,omitempty"`
+	Sample   *sample  `json:"sample,omitempty"`
+	Attempts int      `json:"attempts,omitempty"`
+	Steps    []record `json:"steps,omitempty"`
+
+	Metrics map[string]float64 `json:"metrics,omitempty"`
+	WarmUp  bool               `json:"warm_up,omitempty"`
+}
+
+func newRecord(r *Result) record {
+	rec := record{
+		Duration: ms(r.Duration),
+		Status:   r.Status,
+		Bytes:    r.Bytes,
+		Class:    ErrorClass(r.Error),
+		DNS:      ms(r.Phases.DNS),
+		Connect:  ms(r.Phases.Connect),
+		TLS:      ms(r.Phases.TLS),
+		TTFB:     ms(r.Phases.TTFB),
+		Transfer: ms(r.Phases.Transfer),
+		NewConn:  r.NewConn,
+		Reused:   r.ConnReused,
+		Proto:    r.Proto,
+		Endpoint: r.Endpoint,
+		Attempts: r.Attempts,
+		StatusOK: r.statusOK,
+		Metrics:  r.Metrics,
+		WarmUp:   r.WarmingUp,
+	}
+	if !r.Start.IsZero() {
+		rec.Time = r.Start.Format(time.RFC3339Nano)
+	}
+	if r.Error != nil {
+		rec.Error = r.Error.Error()
+	}
+	if r.CheckError != nil {
+		rec.Check = r.CheckError.Error()
+	}
+	if r.sample != nil {
+		smp := newSample(*r.sample)
+		rec.Sample = &smp
+	}
+	for _, s := range r.Steps {
+		rec.Steps = append(rec.Steps, newRecord(s))
+	}
+	return rec
+}
+
+// NDJSONOutput names the output of each request's result that NDJSON
+// writes, as opposed to a summary that a Format writes.
+const NDJSONOutput = "ndjson"
+
+// ValidOutput checks that name is an output: a Format or NDJSONOutput.
+func ValidOutput(name string) error {
+	if name == NDJSONOutput {
+		return nil
+	}
+	_, err := Format(name)
+	return err
+}
+
+// Format returns the summary reporter for a format name: text, json,
+// csv or html.
+func Format(name string) (Reporter, error) {
+	switch name {
+	case "text":
+		return Text, nil
+	case "json":
+		return JSON, nil
+	case "csv":
+		return CSV, nil
+	case "html":
+		return HTML, nil
+	}
+	return nil, fmt.Errorf("unknown format %q", name)
+}
+
+// ms converts d to fractional milliseconds.
+func ms(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
@@ -0,0 +1,95 @@
+package hit
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestResult() *Result {
+	var sum Result
+	for _, r := range []*Result{
+		{Duration: 10 * time.Millisecond, Status: 200, Bytes: 10, ConnReused: true},
+		{Duration: 20 * time.Millisecond, Status: 200, Bytes: 10},
+		{Duration: 30 * time.Millisecond, Status: 500},
+		{Duration: 40 * time.Millisecond, Error: errors.New("refused")},
+	} {
+		sum.Merge(r)
+	}
+	return sum.Finalize(time.Second)
+}
+
+func TestReportJSON(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	if err := JSON.Report(&out, newTestResult()); err != nil {
+		t.Fatalf("Report() err=%q; want nil", err)
+	}
+	var got summary
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("Unmarshal(%s) err=%q; want nil", out.Bytes(), err)
+	}
+	want := summary{
+		Requests: 4, Errors: 2, Success: 50, RPS: 4, Bytes: 20,
+		Duration: 1000, Fastest: 10, Slowest: 40, P50: 20, P90: 40, P99: 40,
+		Reused: 1, First: 50, Latencies: []float64{10, 20, 30, 40},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("\ngot  %+v\nwant %+v", got, want)
+	}
+}
+
+func TestReportCSV(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	if err := CSV.Report(&out, newTestResult()); err != nil {
+		t.Fatalf("Report() err=%q; want nil", err)
+	}
+	rows, err := csv.NewReader(&out).ReadAll()
+	if err != nil {
+		t.Fatalf("ReadAll() err=%q; want nil", err)
+	}
+	if len(rows) != 2 {
+		t.Fatalf("rows=%d; want 2", len(rows))
+	}
+	if got, want := strings.Join(rows[1], ","), "all,4,2,50,4,20,1000,10,40,20,40,40,0,0,0,0,0,1,0,0,0,0,50"; got != want {
+		t.Errorf("row=%q; want %q", got, want)
+	}
+}
+
+func TestNDJSON(t *testing.T) {
+	t.Parallel()
+
+	var out bytes.Buffer
+	record := NDJSON(&out)
+	record(&Result{Duration: time.Millisecond, Status: 200, Bytes: 2})
+	record(&Result{Duration: time.Millisecond, Error: errors.New("refused")})
+
+	const phases = `"dns_ms":0,"connect_ms":0,"tls_ms":0,"ttfb_ms":0,"transfer_ms":0`
+	want := `{"duration_ms":1,"status":200,"bytes":2,` + phases + `,"reused":false}
+{"duration_ms":1,"status":0,"bytes":0,"error":"refused","class":"other",` + phases + `,"reused":false}
+`
+	if got := out.String(); got != want {
+		t.Errorf("\ngot:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestValidOutput(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"text", "json", "csv", "html", NDJSONOutput} {
+		if err := ValidOutput(name); err != nil {
+			t.Errorf("ValidOutput(%q) err=%q; want nil", name, err)
+		}
+	}
+	if err := ValidOutput("xml"); err == nil {
+		t.Error(`ValidOutput("xml") err=nil; want an error`)
+	}
+}
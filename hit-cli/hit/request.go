@@ -1,39 +1,76 @@
 package hit
 
 import (
-	"fmt"
 	"io"
 	"net/http"
 	"time"
 )
 
+// maxBody is the most of a response body that send keeps for the
+// checks and the extractors.
+const maxBody = 4 << 20
+
 // SendFunc is the type of the function called by Client.Do
 // to send an HTTP request and return a performance result.
 type SendFunc func(*http.Request) *Result
 
+// exchangeFunc sends an HTTP request and returns a performance result,
+// the response header and, if keep is true, the response body.
+type exchangeFunc func(r *http.Request, keep bool) (*Result, http.Header, []byte)
+
 // Send an HTTP request and return a performance result.
 func Send(c *http.Client, r *http.Request) *Result {
-	t := time.Now()
+	result, _, _ := send(c, r, false)
+	return result
+}
+
+// send an HTTP request and return a performance result, the response
+// header and, if keep is true, the response body.
+func send(c *http.Client, r *http.Request, keep bool) (*Result, http.Header, []byte) {
+	var tr tracer
+	r = r.WithContext(tr.trace(r.Context()))
 
 	var (
-		code  int
-		bytes int64
+		code   int
+		bytes  int64
+		proto  string
+		header http.Header
+		body   []byte
 	)
 
 	response, err := c.Do(r)
 	if err == nil {
 		code = response.StatusCode
-		bytes, err = io.Copy(io.Discard, response.Body)
+		proto = response.Proto
+		header = response.Header
+		if keep {
+			body, err = io.ReadAll(io.LimitReader(response.Body, maxBody))
+			bytes = int64(len(body))
+		}
+		if err == nil {
+			// count the rest of the body without keeping it
+			var n int64
+			n, err = io.Copy(io.Discard, response.Body)
+			bytes += n
+		}
 		_ = response.Body.Close()
 	}
 
-	fmt.Printf("request: %s\n", r.URL)
-	time.Sleep(100 * time.Millisecond)
+	end := time.Now()
+	phases, newConn, reused := tr.done(end)
 
-	return &Result{
-		Duration: time.Since(t),
-		Bytes:    bytes,
-		Status:   code,
-		Error:    err,
+	result := &Result{
+		Start:      tr.start,
+		Duration:   end.Sub(tr.start),
+		Bytes:      bytes,
+		Status:     code,
+		Error:      err,
+		Phases:     phases,
+		NewConn:    newConn,
+		ConnReused: reused,
+		Proto:      proto,
+		Endpoint:   endpoint(r.Context()),
+		Attempts:   1,
 	}
+	return result, header, body
 }
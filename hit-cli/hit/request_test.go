@@ -0,0 +1,41 @@
+package hit
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestSendPhases(t *testing.T) {
+	t.Parallel()
+
+	const delay = 10 * time.Millisecond
+
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		_, _ = w.Write([]byte("hello"))
+	})
+	client := server.Client()
+
+	first := Send(client, newRequest(t, http.MethodGet, server.URL))
+	if first.Error != nil {
+		t.Fatalf("Send() err=%q; want nil", first.Error)
+	}
+	if first.ConnReused {
+		t.Error("first request ConnReused=true; want false")
+	}
+	if first.Phases.Connect <= 0 {
+		t.Errorf("Connect=%s; want >0", first.Phases.Connect)
+	}
+	if first.Phases.TTFB < delay {
+		t.Errorf("TTFB=%s; want >=%s", first.Phases.TTFB, delay)
+	}
+
+	second := Send(client, newRequest(t, http.MethodGet, server.URL))
+	if !second.ConnReused {
+		t.Error("second request ConnReused=false; want true")
+	}
+	if second.Phases.Connect != 0 {
+		t.Errorf("Connect=%s; want 0 for a reused connection", second.Phases.Connect)
+	}
+}
@@ -3,7 +3,9 @@ package hit
 import (
 	"fmt"
 	"io"
+	"math"
 	"net/http"
+	"sort"
 	"time"
 )
 
@@ -18,10 +20,102 @@ type Result struct {
 	Slowest  time.Duration
 	Status   int
 	Error    error
+
+	// Start is when a request started.
+	Start time.Time
+
+	// P50, P90 and P99 are the latency percentiles of the requests.
+	P50, P90, P99 time.Duration
+
+	// Phases are a request's phase durations. After Finalize, they are
+	// the average phase durations of the requests.
+	Phases Phases
+	// NewConn and ConnReused report whether a request was sent over a
+	// new or a reused connection. Conns counts the new connections and
+	// Reused counts the requests sent over reused connections.
+	NewConn    bool
+	ConnReused bool
+	Conns      int
+	Reused     int
+
+	// Proto is the protocol of a request's response, such as HTTP/2.0.
+	// Streams counts the requests sent as HTTP/2 streams.
+	Proto   string
+	Streams int
+
+	// Endpoint names a request's endpoint. Endpoints are the aggregated
+	// results of each endpoint.
+	Endpoint  string
+	Endpoints map[string]*Result
+
+	// Steps are the results of a scenario iteration's steps.
+	Steps []*Result
+
+	// WarmingUp reports whether a request was sent during the Client's
+	// warm-up. WarmUp is the aggregated result of such requests, which
+	// the other statistics exclude.
+	WarmingUp bool
+	WarmUp    *Result
+
+	// Saturation is how loaded the Client was during a run.
+	Saturation *Saturation
+
+	// Intervals are the snapshots of a run's progress.
+	Intervals []Snapshot
+
+	// Thresholds are the Client's thresholds evaluated against the
+	// aggregated result.
+	Thresholds []ThresholdResult
+
+	// CheckError is the first check a response failed. CheckFailures
+	// counts such responses and Samples keeps some of them.
+	CheckError    error
+	CheckFailures int
+	Samples       []Sample
+
+	// Attempts is the number of times a request was sent. Retries counts
+	// the retried attempts and FirstSuccess counts the requests that
+	// succeeded at the first attempt.
+	Attempts     int
+	Retries      int
+	FirstSuccess int
+
+	// Metrics are the custom metrics that the Metric middleware records.
+	// After merging, they are the sums of the requests' values.
+	Metrics map[string]float64
+
+	latencies []time.Duration
+	phases    Phases
+	sample    *Sample
+	statusOK  *bool // statusOK is a StatusIn check's verdict on the status, if any
 }
 
 // Merge this result with another
 func (r *Result) Merge(o *Result) {
+	r.merge(o)
+	if o.Endpoint != "" {
+		r.endpoint(o.Endpoint).merge(o)
+	}
+	for _, s := range o.Steps {
+		r.endpoint(s.Endpoint).merge(s)
+	}
+}
+
+// endpoint returns the aggregated result of the named endpoint.
+func (r *Result) endpoint(name string) *Result {
+	if r.Endpoints == nil {
+		r.Endpoints = make(map[string]*Result)
+	}
+	e, ok := r.Endpoints[name]
+	if !ok {
+		e = &Result{Endpoint: name}
+		r.Endpoints[name] = e
+	}
+	return e
+}
+
+// merge o into r without breaking it down into endpoints.
+func (r *Result) merge(o *Result) {
 	r.Requests++
 	r.Bytes += o.Bytes
 
@@ -31,19 +125,76 @@ func (r *Result) Merge(o *Result) {
 	if o.Duration > r.Slowest {
 		r.Slowest = o.Duration
 	}
+	r.latencies = append(r.latencies, o.Duration)
+	r.phases.add(o.Phases)
+	if o.NewConn {
+		r.Conns++
+	}
+	if o.ConnReused {
+		r.Reused++
+	}
+	if o.Proto == "HTTP/2.0" {
+		r.Streams++
+	}
 
-	switch {
-	case o.Error != nil:
-		fallthrough
-	case o.Status >= http.StatusBadRequest:
+	if o.failed() {
 		r.Errors++
+	} else if o.Attempts <= 1 {
+		r.FirstSuccess++
+	}
+	if o.Attempts > 1 {
+		r.Retries += o.Attempts - 1
+	}
+	if o.CheckError != nil {
+		r.CheckFailures++
+	}
+	for name, v := range o.Metrics {
+		if r.Metrics == nil {
+			r.Metrics = make(map[string]float64)
+		}
+		r.Metrics[name] += v
+	}
+	if o.sample != nil && len(r.Samples) < maxSamples {
+		r.Samples = append(r.Samples, *o.sample)
 	}
 }
 
-// Finalize the total duration and calculate RPS.
+// Failed returns the thresholds the result didn't pass.
+func (r *Result) Failed() []ThresholdResult {
+	var failed []ThresholdResult
+	for _, t := range r.Thresholds {
+		if !t.Pass {
+			failed = append(failed, t)
+		}
+	}
+	return failed
+}
+
+// failed reports whether the request failed with an error or an
+// error status code. A StatusIn check decides which statuses are
+// errors if the Client has one.
+func (r *Result) failed() bool {
+	if r.statusOK != nil {
+		return r.Error != nil || !*r.statusOK
+	}
+	return r.Error != nil || r.Status >= http.StatusBadRequest
+}
+
+// Finalize the total duration and calculate RPS. The RPS is zero if
+// the total is not positive.
 func (r *Result) Finalize(total time.Duration) *Result {
 	r.Duration = total
-	r.RPS = float64(r.Requests) / total.Seconds()
+	r.RPS = 0
+	if total > 0 {
+		r.RPS = float64(r.Requests) / total.Seconds()
+	}
+	r.P50 = percentile(r.latencies, 50)
+	r.P90 = percentile(r.latencies, 90)
+	r.P99 = percentile(r.latencies, 99)
+	r.Phases = r.phases.div(r.Requests)
+	for _, e := range r.Endpoints {
+		e.Finalize(total)
+	}
 	return r
 }
 
@@ -59,17 +210,133 @@ func (r *Result) Fprint(out io.Writer) {
 	p("\tErrors		: %d\n", r.Errors)
 	p("\tBytes		: %d\n", r.Bytes)
 	p("\tDuration	: %s\n", round(r.Duration))
+	if r.Retries > 0 {
+		p("\tRetries		: %d\n", r.Retries)
+		p("\tFirst try	: %0.f%% success\n", r.firstSuccess())
+	}
 	if r.Requests > 1 {
 		p("\tFastest		: %s\n", round(r.Fastest))
 		p("\tSlowest		: %s\n", round(r.Slowest))
+		p("\tP50		: %s\n", round(r.P50))
+		p("\tP90		: %s\n", round(r.P90))
+		p("\tP99		: %s\n", round(r.P99))
+	}
+	p("\nPhases (average):\n")
+	p("\tDNS		: %s\n", round(r.Phases.DNS))
+	p("\tConnect		: %s\n", round(r.Phases.Connect))
+	p("\tTLS		: %s\n", round(r.Phases.TLS))
+	p("\tTTFB		: %s\n", round(r.Phases.TTFB))
+	p("\tTransfer	: %s\n", round(r.Phases.Transfer))
+	p("\nConnections:\n")
+	p("\tNew		: %d\n", r.Conns)
+	p("\tReused		: %d of %d requests\n", r.Reused, r.Requests)
+	if r.Streams > 0 {
+		p("\tStreams		: %d HTTP/2 requests\n", r.Streams)
+	}
+	if r.CheckFailures > 0 {
+		p("\nChecks:\n")
+		p("\tFailed		: %d of %d requests\n", r.CheckFailures, r.Requests)
+		for i, s := range r.Samples {
+			if i == 3 {
+				p("\t... see the JSON output for more samples\n")
+				break
+			}
+			p("\t%s: %s\n", s.Endpoint, s.Reason)
+		}
+	}
+	if w := r.WarmUp; w != nil {
+		p("\nWarm-up (excluded):\n")
+		p("\tRequests	: %d\n", w.Requests)
+		p("\tErrors		: %d\n", w.Errors)
+		p("\tDuration	: %s\n", round(w.Duration))
+		p("\tP50		: %s\n", round(w.P50))
+		p("\tP99		: %s\n", round(w.P99))
+	}
+	if s := r.Saturation; s.Saturated() {
+		p("\nClient saturation:\n")
+		for _, w := range s.Warnings {
+			p("\tWARNING		: %s\n", w)
+		}
+		p("\tResults may understate what the target can take.\n")
+	}
+	if s := r.Saturation; s != nil && s.ConcurrencyBound {
+		p("\nConcurrency:\n")
+		p("\tNOTE		: all the workers were busy; the request rate fell behind by %d requests\n", s.Busy)
+		p("\tRaise the concurrency to send the full rate.\n")
 	}
+	if len(r.Metrics) > 0 {
+		p("\nMetrics (total, average):\n")
+		names := make([]string, 0, len(r.Metrics))
+		for name := range r.Metrics {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			v := r.Metrics[name]
+			p("\t%s\t: %g, %.2f\n", name, v, v/float64(r.Requests))
+		}
+	}
+	if len(r.Thresholds) > 0 {
+		p("\nThresholds:\n")
+		for _, t := range r.Thresholds {
+			status := "PASS"
+			if !t.Pass {
+				status = "FAIL"
+			}
+			p("\t%s\t: %s\n", status, t)
+		}
+	}
+	if len(r.Endpoints) > 1 {
+		p("\nEndpoints:\n")
+		for _, e := range r.sortedEndpoints() {
+			p("\t%s\n", e.Endpoint)
+			p("\t\tRequests: %d  Errors: %d  RPS: %.1f  P50: %s  P99: %s\n",
+				e.Requests, e.Errors, e.RPS, round(e.P50), round(e.P99))
+		}
+	}
+}
+
+// sortedEndpoints returns the endpoint results sorted by their names.
+func (r *Result) sortedEndpoints() []*Result {
+	es := make([]*Result, 0, len(r.Endpoints))
+	for _, e := range r.Endpoints {
+		es = append(es, e)
+	}
+	sort.Slice(es, func(i, j int) bool { return es[i].Endpoint < es[j].Endpoint })
+	return es
+}
+
+// firstSuccess returns the percentage of the requests that succeeded
+// at the first attempt.
+func (r *Result) firstSuccess() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+	return float64(r.FirstSuccess) / float64(r.Requests) * 100
 }
 
 func (r *Result) success() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
 	rr, e := float64(r.Requests), float64(r.Errors)
 	return (rr - e) / rr * 100
 }
 
+// percentile returns the pth nearest-rank percentile of the durations.
+// It sorts ds.
+func percentile(ds []time.Duration, p float64) time.Duration {
+	if len(ds) == 0 {
+		return 0
+	}
+	sort.Slice(ds, func(i, j int) bool { return ds[i] < ds[j] })
+	i := int(math.Ceil(p/100*float64(len(ds)))) - 1
+	if i < 0 {
+		i = 0
+	}
+	return ds[i]
+}
+
 func round(t time.Duration) time.Duration {
 	return t.Round(time.Microsecond)
 }
@@ -0,0 +1,243 @@
+package hit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"sort"
+	"time"
+)
+
+// ReadResults reads the results that NDJSON wrote and returns them
+// aggregated, finalized over the time from the first request's start to
+// the last request's end. The result's Intervals are a second long.
+// The warm-up requests are aggregated apart into the result's WarmUp.
+func ReadResults(rd io.Reader) (*Result, error) {
+	var (
+		sum, warm   Result
+		results     []*Result
+		first, last time.Time
+		warmEnd     time.Time
+		d           = json.NewDecoder(rd)
+	)
+	for i := 1; ; i++ {
+		var rec record
+		err := d.Decode(&rec)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", i, err)
+		}
+		r, err := rec.result()
+		if err != nil {
+			return nil, fmt.Errorf("record %d: %w", i, err)
+		}
+		if r.Start.IsZero() {
+			return nil, fmt.Errorf("record %d: no time", i)
+		}
+		results = append(results, r)
+		if first.IsZero() || r.Start.Before(first) {
+			first = r.Start
+		}
+		end := r.Start.Add(r.Duration)
+		if end.After(last) {
+			last = end
+		}
+		if r.WarmingUp {
+			warm.Merge(r)
+			if end.After(warmEnd) {
+				warmEnd = end
+			}
+			continue
+		}
+		sum.Merge(r)
+	}
+	switch {
+	case sum.Requests == 0 && warm.Requests > 0:
+		return nil, errors.New("no results after the warm-up")
+	case sum.Requests == 0:
+		return nil, errors.New("no results")
+	}
+	sum.Intervals = intervals(results, first, last, time.Second)
+	total := last.Sub(first)
+	if warm.Requests > 0 {
+		sum.WarmUp = warm.Finalize(warmEnd.Sub(first))
+		total -= sum.WarmUp.Duration
+	}
+	return sum.Finalize(total), nil
+}
+
+// intervals returns the snapshots of the results' progress every d from
+// start to end by the time the requests ended.
+func intervals(results []*Result, start, end time.Time, d time.Duration) []Snapshot {
+	ended := func(r *Result) time.Time { return r.Start.Add(r.Duration) }
+	sort.Slice(results, func(i, j int) bool {
+		return ended(results[i]).Before(ended(results[j]))
+	})
+
+	var (
+		snapshots []Snapshot
+		iv        = newInterval(start)
+		next      = start.Add(d)
+	)
+	for _, r := range results {
+		for next.Before(ended(r)) {
+			snapshots = append(snapshots, iv.snapshot(next))
+			next = next.Add(d)
+		}
+		iv.add(r)
+	}
+	return append(snapshots, iv.snapshot(end))
+}
+
+// LoadResults reads a results file that NDJSON wrote. See ReadResults.
+func LoadResults(path string) (*Result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadResults(f)
+}
+
+// result returns the request's result the record has the form of.
+func (rec record) result() (*Result, error) {
+	r := &Result{
+		Duration: duration(rec.Duration),
+		Status:   rec.Status,
+		Bytes:    rec.Bytes,
+		Phases: Phases{
+			DNS:      duration(rec.DNS),
+			Connect:  duration(rec.Connect),
+			TLS:      duration(rec.TLS),
+			TTFB:     duration(rec.TTFB),
+			Transfer: duration(rec.Transfer),
+		},
+		NewConn:    rec.NewConn,
+		ConnReused: rec.Reused,
+		Proto:      rec.Proto,
+		Endpoint:   rec.Endpoint,
+		Attempts:   rec.Attempts,
+		statusOK:   rec.StatusOK,
+		Metrics:    rec.Metrics,
+		WarmingUp:  rec.WarmUp,
+	}
+	if rec.Time != "" {
+		t, err := time.Parse(time.RFC3339Nano, rec.Time)
+		if err != nil {
+			return nil, err
+		}
+		r.Start = t
+	}
+	if rec.Error != "" {
+		r.Error = errors.New(rec.Error)
+	}
+	if rec.Check != "" {
+		r.CheckError = errors.New(rec.Check)
+	}
+	if s := rec.Sample; s != nil {
+		r.sample = &Sample{
+			Endpoint: s.Endpoint,
+			Status:   s.Status,
+			Header:   s.Header,
+			Body:     []byte(s.Body),
+			Reason:   s.Reason,
+		}
+	}
+	for _, step := range rec.Steps {
+		s, err := step.result()
+		if err != nil {
+			return nil, err
+		}
+		r.Steps = append(r.Steps, s)
+	}
+	return r, nil
+}
+
+// duration converts milliseconds to a duration.
+func duration(ms float64) time.Duration {
+	return time.Duration(math.Round(ms * float64(time.Millisecond)))
+}
+
+// ReadSummary reads a summary that the JSON reporter wrote back into an
+// aggregated result. The result's latencies are the summary's sample of
+// latencies, which is enough to Compare it with another result.
+func ReadSummary(rd io.Reader) (*Result, error) {
+	var s summary
+	if err := json.NewDecoder(rd).Decode(&s); err != nil {
+		return nil, fmt.Errorf("summary: %w", err)
+	}
+	return s.result(), nil
+}
+
+// LoadSummary reads a summary file that the JSON reporter wrote. See
+// ReadSummary.
+func LoadSummary(path string) (*Result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ReadSummary(f)
+}
+
+// result returns the aggregated result the summary has the form of.
+func (s summary) result() *Result {
+	r := &Result{
+		RPS:      s.RPS,
+		Requests: s.Requests,
+		Errors:   s.Errors,
+		Bytes:    s.Bytes,
+		Duration: duration(s.Duration),
+		Fastest:  duration(s.Fastest),
+		Slowest:  duration(s.Slowest),
+		P50:      duration(s.P50),
+		P90:      duration(s.P90),
+		P99:      duration(s.P99),
+		Phases: Phases{
+			DNS:      duration(s.DNS),
+			Connect:  duration(s.Connect),
+			TLS:      duration(s.TLS),
+			TTFB:     duration(s.TTFB),
+			Transfer: duration(s.Transfer),
+		},
+		Reused:        s.Reused,
+		Conns:         s.Conns,
+		Streams:       s.Streams,
+		CheckFailures: s.Checks,
+		Retries:       s.Retries,
+		FirstSuccess:  int(math.Round(s.First * float64(s.Requests) / 100)),
+		Metrics:       s.Metrics,
+	}
+	for _, l := range s.Latencies {
+		r.latencies = append(r.latencies, duration(l))
+	}
+	for name, e := range s.Endpoints {
+		if r.Endpoints == nil {
+			r.Endpoints = make(map[string]*Result, len(s.Endpoints))
+		}
+		r.Endpoints[name] = e.result()
+		r.Endpoints[name].Endpoint = name
+	}
+	if s.WarmUp != nil {
+		r.WarmUp = s.WarmUp.result()
+	}
+	if sat := s.Saturation; sat != nil {
+		r.Saturation = &Saturation{
+			Goroutines:       sat.Goroutines,
+			CPU:              sat.CPU,
+			GCPause:          duration(sat.GCPause),
+			GCs:              sat.GCs,
+			SchedLag:         duration(sat.SchedLag),
+			Missed:           sat.Missed,
+			Busy:             sat.Busy,
+			ConcurrencyBound: sat.Bound,
+			Warnings:         sat.Warnings,
+		}
+	}
+	return r
+}
@@ -0,0 +1,113 @@
+package hit
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReadResults(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	expected := true
+	results := []*Result{
+		{Start: start, Duration: 10 * time.Millisecond, Status: 200, Bytes: 10, ConnReused: true, Endpoint: "a"},
+		{Start: start.Add(100 * time.Millisecond), Duration: 20 * time.Millisecond, Status: 200, Bytes: 10, NewConn: true, Endpoint: "b"},
+		{Start: start.Add(500 * time.Millisecond), Duration: 30 * time.Millisecond, Status: 500, Endpoint: "a",
+			CheckError: errors.New("status 500"), sample: &Sample{Status: 500, Body: []byte("oops"), Reason: "status 500"}},
+		{Start: start.Add(900 * time.Millisecond), Duration: 10 * time.Millisecond, Status: 404, Endpoint: "a", statusOK: &expected},
+		{Start: start.Add(960 * time.Millisecond), Duration: 40 * time.Millisecond, Error: errors.New("refused"), Attempts: 3, Endpoint: "b"},
+	}
+
+	var (
+		out  bytes.Buffer
+		want Result
+	)
+	record := NDJSON(&out)
+	for _, r := range results {
+		record(r)
+		want.Merge(r)
+	}
+	want.Finalize(time.Second)
+
+	got, err := ReadResults(&out)
+	if err != nil {
+		t.Fatalf("ReadResults() err=%q; want nil", err)
+	}
+	if g, w := newSummary(got), newSummary(&want); !reflect.DeepEqual(g, w) {
+		t.Errorf("\ngot  %+v\nwant %+v", g, w)
+	}
+}
+
+func TestReadResultsWarmUp(t *testing.T) {
+	t.Parallel()
+
+	start := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	var out bytes.Buffer
+	record := NDJSON(&out)
+	for i, warm := range []bool{true, true, false} {
+		record(&Result{
+			Start:     start.Add(time.Duration(i) * 100 * time.Millisecond),
+			Duration:  100 * time.Millisecond,
+			Status:    200,
+			WarmingUp: warm,
+		})
+	}
+
+	got, err := ReadResults(&out)
+	if err != nil {
+		t.Fatalf("ReadResults() err=%q; want nil", err)
+	}
+	if got.Requests != 1 {
+		t.Errorf("Requests=%d; want 1 without the warm-up", got.Requests)
+	}
+	if got.WarmUp == nil || got.WarmUp.Requests != 2 {
+		t.Errorf("WarmUp=%+v; want 2 requests", got.WarmUp)
+	}
+	if got.Duration != 100*time.Millisecond {
+		t.Errorf("Duration=%s; want 100ms after the warm-up", got.Duration)
+	}
+}
+
+func TestReadResultsZeroSpan(t *testing.T) {
+	t.Parallel()
+
+	in := `{"time":"2024-01-02T03:04:05Z","duration_ms":0,"status":200}`
+	got, err := ReadResults(strings.NewReader(in))
+	if err != nil {
+		t.Fatalf("ReadResults() err=%q; want nil", err)
+	}
+	if got.RPS != 0 {
+		t.Errorf("RPS=%v; want 0 over no time", got.RPS)
+	}
+	if err := JSON.Report(io.Discard, got); err != nil {
+		t.Errorf("JSON() err=%q; want nil", err)
+	}
+}
+
+func TestReadResultsError(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]string{
+		"empty":   "",
+		"warm up": `{"time":"2024-01-02T03:04:05Z","duration_ms":1,"status":200,"warm_up":true}`,
+		"json":    `{"duration_ms":`,
+		"no time": `{"duration_ms":1,"status":200}`,
+		"time":    `{"time":"yesterday","duration_ms":1}`,
+	}
+	for name, in := range tests {
+		in := in
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := ReadResults(strings.NewReader(in)); err == nil {
+				t.Errorf("ReadResults(%q) err=nil; want an error", in)
+			}
+		})
+	}
+}
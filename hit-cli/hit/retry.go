@@ -0,0 +1,112 @@
+package hit
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// RetryPolicy retries failed requests with an exponential backoff.
+type RetryPolicy struct {
+	// MaxAttempts is the number of attempts, including the first one.
+	MaxAttempts int
+	// Statuses are the status codes to retry. Defaults to 429, 502,
+	// 503 and 504.
+	Statuses []int
+	// Errors are the error classes to retry. Defaults to timeout,
+	// refused, reset and connection errors.
+	Errors []string
+	// Backoff is the delay before the first retry. It doubles with each
+	// retry up to MaxBackoff, if it's set.
+	Backoff, MaxBackoff time.Duration
+	// Jitter randomizes each delay between its half and itself.
+	Jitter bool
+	// RetryAfter waits as long as a response's Retry-After header says
+	// instead of backing off.
+	RetryAfter bool
+}
+
+var (
+	defaultRetryStatuses = []int{
+		http.StatusTooManyRequests, http.StatusBadGateway,
+		http.StatusServiceUnavailable, http.StatusGatewayTimeout,
+	}
+	defaultRetryErrors = []string{ErrTimeout, ErrRefused, ErrReset, ErrConnection}
+)
+
+// retry reports whether to retry a request after an attempt.
+func (p *RetryPolicy) retry(attempt int, result *Result) bool {
+	if attempt >= p.MaxAttempts {
+		return false
+	}
+	if result.Error != nil {
+		class := ErrorClass(result.Error)
+		for _, c := range orDefault(p.Errors, defaultRetryErrors) {
+			if c == class {
+				return true
+			}
+		}
+		return false
+	}
+	for _, s := range orDefault(p.Statuses, defaultRetryStatuses) {
+		if s == result.Status {
+			return true
+		}
+	}
+	return false
+}
+
+// delay returns how long to wait before the next attempt.
+func (p *RetryPolicy) delay(attempt int, header http.Header) time.Duration {
+	if p.RetryAfter {
+		if d, ok := retryAfter(header.Get("Retry-After")); ok {
+			return d
+		}
+	}
+	d := p.Backoff << (attempt - 1)
+	if p.MaxBackoff > 0 && (d > p.MaxBackoff || d <= 0) {
+		d = p.MaxBackoff
+	}
+	if p.Jitter && d > 1 {
+		d = d/2 + time.Duration(rand.Int63n(int64(d/2)))
+	}
+	return d
+}
+
+// retryAfter parses a Retry-After header in seconds or as an HTTP date.
+func retryAfter(v string) (time.Duration, bool) {
+	if v == "" {
+		return 0, false
+	}
+	if s, err := strconv.Atoi(v); err == nil && s >= 0 {
+		return time.Duration(s) * time.Second, true
+	}
+	if t, err := http.ParseTime(v); err == nil {
+		if d := time.Until(t); d > 0 {
+			return d, true
+		}
+		return 0, true
+	}
+	return 0, false
+}
+
+// sleep waits for d or until ctx is done.
+func sleep(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func orDefault[T any](v, def []T) []T {
+	if len(v) == 0 {
+		return def
+	}
+	return v
+}
@@ -0,0 +1,104 @@
+package hit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestClientDoRetry(t *testing.T) {
+	t.Parallel()
+
+	const hits = 5
+
+	var (
+		attempts atomic.Int64
+		server   = newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			if b, _ := io.ReadAll(r.Body); string(b) != "body" {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			// fail the first two attempts of each request
+			if attempts.Add(1)%3 != 0 {
+				w.Header().Set("Retry-After", "0")
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}
+		})
+	)
+	r, err := http.NewRequest(http.MethodPost, server.URL, strings.NewReader("body"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Client{
+		C: 1,
+		Retry: &RetryPolicy{
+			MaxAttempts: 3,
+			Backoff:     time.Hour, // Retry-After overrides it
+			RetryAfter:  true,
+		},
+	}
+	sum := c.Do(context.Background(), r, hits)
+	if sum.Errors != 0 {
+		t.Errorf("Errors=%d; want 0", sum.Errors)
+	}
+	if sum.FirstSuccess != 0 {
+		t.Errorf("FirstSuccess=%d; want 0", sum.FirstSuccess)
+	}
+	if want := 2 * hits; sum.Retries != want {
+		t.Errorf("Retries=%d; want %d", sum.Retries, want)
+	}
+}
+
+func TestRetryPolicyDelay(t *testing.T) {
+	t.Parallel()
+
+	p := &RetryPolicy{Backoff: 100 * time.Millisecond, MaxBackoff: time.Second}
+	for attempt, want := range map[int]time.Duration{
+		1:  100 * time.Millisecond,
+		2:  200 * time.Millisecond,
+		4:  800 * time.Millisecond,
+		5:  time.Second,
+		70: time.Second,
+	} {
+		if got := p.delay(attempt, nil); got != want {
+			t.Errorf("delay(%d)=%s; want %s", attempt, got, want)
+		}
+	}
+
+	p.Jitter = true
+	for i := 0; i < 100; i++ {
+		if got := p.delay(2, nil); got < 100*time.Millisecond || got >= 200*time.Millisecond {
+			t.Fatalf("delay(2) with jitter=%s; want in [100ms, 200ms)", got)
+		}
+	}
+}
+
+func TestErrorClass(t *testing.T) {
+	t.Parallel()
+
+	refused := Send(http.DefaultClient, newRequest(t, http.MethodGet, "http://127.0.0.1:1")).Error
+
+	tests := map[string]error{
+		"":            nil,
+		ErrCanceled:   fmt.Errorf("get: %w", context.Canceled),
+		ErrTimeout:    fmt.Errorf("get: %w", context.DeadlineExceeded),
+		ErrDNS:        &net.DNSError{Err: "no such host", Name: "foo"},
+		ErrRefused:    refused,
+		ErrReset:      fmt.Errorf("get: %w", io.ErrUnexpectedEOF),
+		ErrConnection: &net.OpError{Op: "dial", Err: errors.New("no route")},
+		ErrOther:      errors.New("bad request"),
+	}
+	for want, err := range tests {
+		if got := ErrorClass(err); got != want {
+			t.Errorf("ErrorClass(%v)=%q; want %q", err, got, want)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package hit
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Scenario is an ordered list of steps that each virtual user runs in
+// an iteration. The steps extract values from their responses into
+// variables that fill in the {{var}} placeholders of the next steps.
+type Scenario struct {
+	Name  string `json:"name,omitempty"`
+	Steps []Step `json:"steps"`
+
+	// Feeder fills in the placeholders of the first step. The next
+	// steps can use its fields too.
+	Feeder *Feeder `json:"-"`
+}
+
+// Step is a request of a Scenario.
+type Step struct {
+	Template
+	Extract []Extractor `json:"extract,omitempty"`
+}
+
+// Extractor extracts a variable from a step's response. Set one of
+// JSON, Header or Regexp.
+type Extractor struct {
+	Var    string `json:"var"`
+	JSON   string `json:"json,omitempty"`   // JSON is a dot separated path, like data.items.0.key
+	Header string `json:"header,omitempty"` // Header is the name of a response header
+	Regexp string `json:"regexp,omitempty"` // Regexp extracts its first group, or its match, from the body
+
+	re *regexp.Regexp
+}
+
+// LoadScenario reads a scenario from a JSON file.
+func LoadScenario(path string) (*Scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var sc Scenario
+	if err := json.Unmarshal(data, &sc); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	if err := sc.init(); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return &sc, nil
+}
+
+// init sets the scenario's defaults and checks its steps.
+func (sc *Scenario) init() error {
+	if len(sc.Steps) == 0 {
+		return errors.New("scenario has no steps")
+	}
+	for i := range sc.Steps {
+		step := &sc.Steps[i]
+		if err := step.init(); err != nil {
+			return fmt.Errorf("step %d: %w", i+1, err)
+		}
+		for j := range step.Extract {
+			if err := step.Extract[j].init(); err != nil {
+				return fmt.Errorf("%s: %w", step.Name, err)
+			}
+		}
+	}
+	return nil
+}
+
+func (e *Extractor) init() error {
+	if e.Var == "" {
+		return errors.New("extract: missing var")
+	}
+	n := 0
+	for _, s := range []string{e.JSON, e.Header, e.Regexp} {
+		if s != "" {
+			n++
+		}
+	}
+	if n != 1 {
+		return fmt.Errorf("extract %s: set one of json, header or regexp", e.Var)
+	}
+	if e.Regexp == "" {
+		return nil
+	}
+	var err error
+	if e.re, err = regexp.Compile(e.Regexp); err != nil {
+		return fmt.Errorf("extract %s: %w", e.Var, err)
+	}
+	return nil
+}
+
+// send returns a function that runs an iteration of the scenario as the
+// virtual user with exchange. It receives the first step's request and returns the
+// iteration's result with the results of its steps. An iteration stops
+// at a failed step.
+func (sc *Scenario) send(vu *VU, exchange exchangeFunc) SendFunc {
+	return func(r *http.Request) *Result {
+		var (
+			ctx   = r.Context()
+			vars  = Fields{}
+			start = time.Now()
+			it    Result
+		)
+		for k, v := range vu.Vars {
+			vars[k] = v
+		}
+		for k, v := range fields(ctx) {
+			vars[k] = v
+		}
+		for i, step := range sc.Steps {
+			if i > 0 {
+				var err error
+				// the variables can make an invalid request
+				if r, err = step.request(ctx, vars); err != nil {
+					it.Error = fmt.Errorf("%s: %w", step.Name, err)
+					break
+				}
+			}
+			result, header, body := exchange(r, len(step.Extract) > 0)
+			it.Steps = append(it.Steps, result)
+			it.Bytes += result.Bytes
+			it.Status, it.statusOK = result.Status, result.statusOK
+			if it.CheckError == nil && result.CheckError != nil {
+				it.CheckError = fmt.Errorf("%s: %w", step.Name, result.CheckError)
+				it.sample = result.sample
+			}
+			switch {
+			case result.Error != nil:
+				it.Error = fmt.Errorf("%s: %w", step.Name, result.Error)
+			case result.failed():
+				it.Error = fmt.Errorf("%s: status %d", step.Name, result.Status)
+			default:
+				it.Error = step.extract(vars, header, body)
+			}
+			if it.Error != nil {
+				break
+			}
+		}
+		it.Start = start
+		it.Duration = time.Since(start)
+		return &it
+	}
+}
+
+// extract the step's variables from a response.
+func (s *Step) extract(vars Fields, header http.Header, body []byte) error {
+	for _, e := range s.Extract {
+		v, err := e.extract(header, body)
+		if err != nil {
+			return fmt.Errorf("%s: extract %s: %w", s.Name, e.Var, err)
+		}
+		vars[e.Var] = v
+	}
+	return nil
+}
+
+func (e *Extractor) extract(header http.Header, body []byte) (string, error) {
+	switch {
+	case e.Header != "":
+		if v := header.Get(e.Header); v != "" {
+			return v, nil
+		}
+		return "", fmt.Errorf("no %s header", e.Header)
+	case e.re != nil:
+		m := e.re.FindSubmatch(body)
+		switch {
+		case m == nil:
+			return "", errors.New("no match")
+		case len(m) > 1:
+			return string(m[1]), nil
+		}
+		return string(m[0]), nil
+	}
+	return extractJSON(body, e.JSON)
+}
+
+// extractJSON returns the value at a dot separated path of a JSON
+// document. Numbers in the path index arrays.
+func extractJSON(data []byte, path string) (string, error) {
+	var v any
+	if err := json.Unmarshal(data, &v); err != nil {
+		return "", err
+	}
+	for _, key := range strings.Split(path, ".") {
+		switch node := v.(type) {
+		case map[string]any:
+			v = node[key]
+		case []any:
+			i, err := strconv.Atoi(key)
+			if err != nil || i < 0 || i >= len(node) {
+				return "", fmt.Errorf("%s: no index %q", path, key)
+			}
+			v = node[i]
+		default:
+			v = nil
+		}
+		if v == nil {
+			return "", fmt.Errorf("%s: no field %q", path, key)
+		}
+	}
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	data, err := json.Marshal(v)
+	return string(data), err
+}
@@ -0,0 +1,163 @@
+package hit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClientRunScenario(t *testing.T) {
+	t.Parallel()
+
+	const iterations = 10
+
+	var (
+		keys     atomic.Int64
+		resolved atomic.Int64
+		server   = newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			switch {
+			case r.URL.Path == "/s":
+				w.Header().Set("Location", "/r/")
+				w.WriteHeader(http.StatusCreated)
+				fmt.Fprintf(w, `{"data":{"key":"k%d"}}`, keys.Add(1))
+			case strings.HasPrefix(r.URL.Path, "/r/k"):
+				if r.Header.Get("Referer") == "/r/" {
+					resolved.Add(1)
+				}
+			default:
+				http.NotFound(w, r)
+			}
+		})
+	)
+
+	path := filepath.Join(t.TempDir(), "scenario.json")
+	scenario := `{
+		"name": "shorten and resolve",
+		"steps": [
+			{
+				"name": "shorten",
+				"method": "POST",
+				"url": "` + server.URL + `/s",
+				"body": "{\"url\":\"https://go.dev\"}",
+				"extract": [
+					{"var": "key", "json": "data.key"},
+					{"var": "location", "header": "Location"}
+				]
+			},
+			{
+				"name": "resolve",
+				"url": "` + server.URL + `/r/{{key}}",
+				"header": {"Referer": ["{{location}}"]}
+			}
+		]
+	}`
+	if err := os.WriteFile(path, []byte(scenario), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	sc, err := LoadScenario(path)
+	if err != nil {
+		t.Fatalf("LoadScenario() err=%q; want nil", err)
+	}
+
+	c := &Client{C: 1}
+	sum, err := c.RunScenario(context.Background(), sc, iterations)
+	if err != nil {
+		t.Fatalf("RunScenario() err=%q; want nil", err)
+	}
+	if sum.Requests != iterations || sum.Errors != 0 {
+		t.Errorf("Requests=%d, Errors=%d; want %d, 0", sum.Requests, sum.Errors, iterations)
+	}
+	if got := resolved.Load(); got != iterations {
+		t.Errorf("resolved=%d; want %d", got, iterations)
+	}
+	for _, step := range []string{"shorten", "resolve"} {
+		if e := sum.Endpoints[step]; e == nil || e.Requests != iterations {
+			t.Errorf("Endpoints[%q]=%+v; want %d requests", step, e, iterations)
+		}
+	}
+}
+
+func TestClientRunScenarioFailedStep(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"id":1}`))
+	})
+
+	sc := &Scenario{Steps: []Step{
+		{
+			Template: Template{Name: "create", URL: server.URL},
+			Extract:  []Extractor{{Var: "key", JSON: "key"}},
+		},
+		{Template: Template{Name: "resolve", URL: server.URL + "/{{key}}"}},
+	}}
+	sum, err := (&Client{C: 1}).RunScenario(context.Background(), sc, 2)
+	if err != nil {
+		t.Fatalf("RunScenario() err=%q; want nil", err)
+	}
+	if sum.Errors != 2 {
+		t.Errorf("Errors=%d; want 2", sum.Errors)
+	}
+	if e := sum.Endpoints["resolve"]; e != nil {
+		t.Errorf("resolve ran %d times after a failed extraction; want 0", e.Requests)
+	}
+}
+
+func TestClientRunScenarioBadRequest(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t, func(http.ResponseWriter, *http.Request) {})
+	sc := &Scenario{
+		Steps: []Step{
+			{Template: Template{Name: "first", URL: server.URL}},
+			{Template: Template{Name: "second", URL: "http://{{h}}/"}},
+		},
+		Feeder: &Feeder{Rows: []Fields{{"h": "a b"}}},
+	}
+	sum, err := (&Client{C: 1}).RunScenario(context.Background(), sc, 1)
+	if err != nil {
+		t.Fatalf("RunScenario() err=%q; want nil", err)
+	}
+	if sum.Requests != 1 || sum.Errors != 1 {
+		t.Errorf("Requests=%d, Errors=%d; want 1, 1: the second step's request is invalid", sum.Requests, sum.Errors)
+	}
+	if e := sum.Endpoints["second"]; e != nil {
+		t.Errorf("sent the invalid second step %d times; want 0", e.Requests)
+	}
+}
+
+func TestExtractJSON(t *testing.T) {
+	t.Parallel()
+
+	const doc = `{"data":{"items":[{"key":"a"},{"key":"b","n":2}]}}`
+	tests := map[string]struct {
+		path, want string
+		wantErr    bool
+	}{
+		"string":  {path: "data.items.1.key", want: "b"},
+		"number":  {path: "data.items.1.n", want: "2"},
+		"object":  {path: "data.items.0", want: `{"key":"a"}`},
+		"missing": {path: "data.nope", wantErr: true},
+		"index":   {path: "data.items.2", wantErr: true},
+		"scalar":  {path: "data.items.0.key.x", wantErr: true},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := extractJSON([]byte(doc), tt.path)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("extractJSON(%q) err=%v; want err=%t", tt.path, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("extractJSON(%q)=%q; want %q", tt.path, got, tt.want)
+			}
+		})
+	}
+}
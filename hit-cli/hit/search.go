@@ -0,0 +1,233 @@
+package hit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"strings"
+	"time"
+)
+
+// SearchMode is the load that a Search changes: the request rate or
+// the concurrency level.
+type SearchMode int
+
+// Search modes.
+const (
+	SearchRPS SearchMode = iota
+	SearchConcurrency
+)
+
+// ParseSearchMode parses a search mode name: rps or concurrency.
+func ParseSearchMode(s string) (SearchMode, error) {
+	switch s {
+	case "rps":
+		return SearchRPS, nil
+	case "concurrency":
+		return SearchConcurrency, nil
+	}
+	return 0, fmt.Errorf("unknown search mode %q", s)
+}
+
+func (m SearchMode) String() string {
+	if m == SearchConcurrency {
+		return "concurrency"
+	}
+	return "rps"
+}
+
+// Search finds the highest load that a target sustains under an SLO. It
+// runs steps of Duration at increasing loads, doubling from Min until a
+// step violates the SLO or reaches Max, and then bisects between the
+// highest load that passed and the lowest that failed.
+type Search struct {
+	// Client sends the requests of each step with the step's request
+	// rate or concurrency level. Its own Thresholds are ignored. In
+	// SearchRPS mode, its C is the least concurrency level: a step uses
+	// a worker per request per second of its rate, at least.
+	Client *Client
+	Mode   SearchMode
+
+	// SLO are the thresholds that each step must pass. In SearchRPS
+	// mode, a step also fails if it falls short of its request rate,
+	// because the target or the Client could not keep up. The step's
+	// Failed says which, from the Client's Saturation.
+	SLO []Threshold
+
+	// Min and Max bound the searched loads. Min defaults to 1.
+	Min, Max int
+	// Duration is the length of each step. It defaults to 10s.
+	Duration time.Duration
+	// Precision stops the search when the highest load that passed and
+	// the lowest that failed are apart by this fraction of the former
+	// or less. It defaults to 5%.
+	Precision float64
+
+	// OnStep is called with each step's result.
+	OnStep func(SearchStep)
+}
+
+// SearchStep is a step of a search at a load.
+type SearchStep struct {
+	Load   int
+	Result *Result
+	Pass   bool
+	// Failed are the reasons why the step failed.
+	Failed []string
+}
+
+// SearchResult is the result of a search. Best is the highest load
+// that passed, or zero if none did, and Result is its step's result.
+type SearchResult struct {
+	Mode   SearchMode
+	Best   int
+	Result *Result
+	Steps  []SearchStep
+}
+
+// Search defaults.
+const (
+	defaultSearchDuration  = 10 * time.Second
+	defaultSearchPrecision = 0.05
+
+	// searchShortfall is the fraction of a step's request rate below
+	// which the step fails in SearchRPS mode.
+	searchShortfall = 0.9
+)
+
+// Run searches the loads with the requests from the source. It returns
+// the steps so far if the context is canceled.
+func (s *Search) Run(ctx context.Context, src Source) (*SearchResult, error) {
+	lo, hi := s.Min, s.Max
+	if lo <= 0 {
+		lo = 1
+	}
+	if hi < lo {
+		return nil, fmt.Errorf("search: max %d is less than min %d", hi, lo)
+	}
+	if len(s.SLO) == 0 {
+		return nil, errors.New("search: no SLO")
+	}
+
+	var (
+		sr           = &SearchResult{Mode: s.Mode}
+		pass, fail   int // the highest load that passed and the lowest that failed
+		load         = lo
+		precision    = s.Precision
+		bisect, done bool
+	)
+	if precision <= 0 {
+		precision = defaultSearchPrecision
+	}
+	for !done {
+		step := s.step(ctx, src, load)
+		if err := ctx.Err(); err != nil {
+			return sr, err
+		}
+		sr.Steps = append(sr.Steps, step)
+		if s.OnStep != nil {
+			s.OnStep(step)
+		}
+		if step.Pass {
+			pass, sr.Best, sr.Result = load, load, step.Result
+		} else {
+			fail, bisect = load, true
+		}
+
+		switch {
+		case !bisect && load >= hi:
+			done = true
+		case !bisect:
+			load *= 2
+			if load > hi {
+				load = hi
+			}
+		case pass == 0, fail-pass <= 1, float64(fail-pass) <= float64(pass)*precision:
+			done = true
+		default:
+			load = pass + (fail-pass)/2
+		}
+	}
+	return sr, nil
+}
+
+// step runs a step at a load.
+func (s *Search) step(ctx context.Context, src Source, load int) SearchStep {
+	c := *s.Client
+	c.Thresholds = s.SLO
+	d := s.Duration
+	if d <= 0 {
+		d = defaultSearchDuration
+	}
+
+	timed := until(time.Now().Add(d), src)
+	switch s.Mode {
+	case SearchRPS:
+		// a second for each request of a worker, so that the workers
+		// fall short of the rate only if the target is slow
+		if c.C < load {
+			c.C = load
+		}
+		c.RPS = 0
+		c.Stages = Stages{{Target: load}, {Duration: d, Target: load}}
+	case SearchConcurrency:
+		c.C = load
+		c.Stages = nil
+	}
+	sum := c.Run(ctx, timed, math.MaxInt)
+
+	step := SearchStep{Load: load, Result: sum}
+	for _, t := range sum.Failed() {
+		step.Failed = append(step.Failed, t.String())
+	}
+	if s.Mode == SearchRPS {
+		if reason := shortfall(sum, load, d); reason != "" {
+			step.Failed = append(step.Failed, reason)
+		}
+	}
+	step.Pass = len(step.Failed) == 0
+	return step
+}
+
+// shortfall returns why a step that sent requests at a rate for d fell
+// short of the rate, or "" if it did not.
+func shortfall(sum *Result, rate int, d time.Duration) string {
+	// one request of slack for the rate's first and last ticks
+	if want := float64(rate) * d.Seconds(); float64(sum.Requests+1) >= searchShortfall*want {
+		return ""
+	}
+	reason := fmt.Sprintf("rps %.1f is short of %d", sum.RPS, rate)
+	if sum.Saturation.Saturated() {
+		return reason + ", client-bound: " + strings.Join(sum.Saturation.Warnings, "; ")
+	}
+	return reason
+}
+
+// Fprint prints the steps and the highest load that passed.
+func (sr *SearchResult) Fprint(out io.Writer) {
+	p := func(format string, args ...any) {
+		fmt.Fprintf(out, format, args...)
+	}
+	p("\nSteps:\n")
+	for _, s := range sr.Steps {
+		status := "PASS"
+		if !s.Pass {
+			status = "FAIL"
+		}
+		p("\t%s\t%s %-6d: %8.1f rps  %5.1f%% errors  p50 %-8s p99 %s",
+			status, sr.Mode, s.Load, s.Result.RPS, 100-s.Result.success(),
+			round(s.Result.P50), round(s.Result.P99))
+		for _, f := range s.Failed {
+			p("  %s", f)
+		}
+		p("\n")
+	}
+	if sr.Result == nil {
+		p("\nNo load passed the SLO.\n")
+		return
+	}
+	p("\nHighest sustainable %s: %d\n", sr.Mode, sr.Best)
+	sr.Result.Fprint(out)
+}
@@ -0,0 +1,128 @@
+package hit
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSearchConcurrency(t *testing.T) {
+	t.Parallel()
+
+	// the server fails the requests over its capacity
+	const capacity = 4
+	var inflight atomic.Int64
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		defer inflight.Add(-1)
+		if inflight.Add(1) > capacity {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	})
+	errRate, err := ParseThreshold("errors<1%")
+	if err != nil {
+		t.Fatalf("ParseThreshold err=%q; want nil", err)
+	}
+
+	var steps int
+	s := &Search{
+		Client:   &Client{},
+		Mode:     SearchConcurrency,
+		SLO:      []Threshold{errRate},
+		Min:      1,
+		Max:      64,
+		Duration: 100 * time.Millisecond,
+		OnStep:   func(SearchStep) { steps++ },
+	}
+	sr, err := s.Run(context.Background(), Clone(newRequest(t, http.MethodGet, server.URL)))
+	if err != nil {
+		t.Fatalf("Run err=%q; want nil", err)
+	}
+	if sr.Best != capacity {
+		t.Errorf("Best=%d; want %d", sr.Best, capacity)
+	}
+	if sr.Result == nil || sr.Result.Requests == 0 {
+		t.Errorf("Result=%v; want the best step's result", sr.Result)
+	}
+	if steps != len(sr.Steps) {
+		t.Errorf("OnStep called %d times; want %d", steps, len(sr.Steps))
+	}
+	// 1, 2, 4 and 8, then 6 and 5
+	var loads []int
+	for _, st := range sr.Steps {
+		loads = append(loads, st.Load)
+	}
+	if len(loads) != 6 || loads[3] != 8 || loads[5] != 5 {
+		t.Errorf("loads=%v; want [1 2 4 8 6 5]", loads)
+	}
+}
+
+func TestSearchRPS(t *testing.T) {
+	t.Parallel()
+
+	// a worker sends at most 50 rps: the steps need more workers
+	server := newTestServer(t, func(http.ResponseWriter, *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+	})
+	p99, err := ParseThreshold("p99<1s")
+	if err != nil {
+		t.Fatalf("ParseThreshold err=%q; want nil", err)
+	}
+	s := &Search{
+		Client:   &Client{C: 1},
+		SLO:      []Threshold{p99},
+		Min:      50,
+		Max:      100,
+		Duration: 200 * time.Millisecond,
+	}
+	sr, err := s.Run(context.Background(), Clone(newRequest(t, http.MethodGet, server.URL)))
+	if err != nil {
+		t.Fatalf("Run err=%q; want nil", err)
+	}
+	if sr.Best != 100 {
+		t.Errorf("Best=%d; want 100 when every step passes", sr.Best)
+	}
+	if got := len(sr.Steps); got != 2 {
+		t.Errorf("steps=%d; want 2", got)
+	}
+}
+
+func TestShortfall(t *testing.T) {
+	t.Parallel()
+
+	saturated := &Saturation{Warnings: []string{"the client used 95% of the CPUs"}}
+	tests := map[string]struct {
+		sum  *Result
+		want string
+	}{
+		"kept up": {&Result{Requests: 100, RPS: 100}, ""},
+		"target":  {&Result{Requests: 50, RPS: 50}, "rps 50.0 is short of 100"},
+		"client": {&Result{Requests: 50, RPS: 50, Saturation: saturated},
+			"rps 50.0 is short of 100, client-bound: the client used 95% of the CPUs"},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := shortfall(tt.sum, 100, time.Second); got != tt.want {
+				t.Errorf("shortfall()=%q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSearchInvalid(t *testing.T) {
+	t.Parallel()
+
+	src := Clone(newRequest(t, http.MethodGet, "/"))
+	if _, err := (&Search{Client: &Client{}, Min: 10, Max: 5}).Run(context.Background(), src); err == nil {
+		t.Error("Run with max < min err=nil; want an error")
+	}
+	if _, err := (&Search{Client: &Client{}, Max: 5}).Run(context.Background(), src); err == nil {
+		t.Error("Run without an SLO err=nil; want an error")
+	}
+}
@@ -0,0 +1,191 @@
+package hit
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// reference matches the ${NAME} and ${file:/path} references that
+// Interpolate replaces.
+var reference = regexp.MustCompile(`\$\{([^{}]+)\}`)
+
+// redacted replaces the interpolated values in the output.
+const redacted = "***"
+
+// Interpolate replaces the ${NAME} references in s with the values of
+// the environment variables, and the ${file:/path} references with the
+// contents of the files without their trailing newline. Redact hides
+// the values from then on, so that they do not appear in results,
+// reports or logs.
+func Interpolate(s string) (string, error) {
+	var err error
+	s = reference.ReplaceAllStringFunc(s, func(ref string) string {
+		v, lerr := lookup(reference.FindStringSubmatch(ref)[1])
+		if lerr != nil {
+			if err == nil {
+				err = lerr
+			}
+			return ref
+		}
+		secrets.add(v)
+		return v
+	})
+	return s, err
+}
+
+// lookup returns the value of a reference.
+func lookup(name string) (string, error) {
+	if strings.HasPrefix(name, "file:") {
+		data, err := os.ReadFile(strings.TrimPrefix(name, "file:"))
+		if err != nil {
+			return "", err
+		}
+		return strings.TrimRight(string(data), "\r\n"), nil
+	}
+	v, ok := os.LookupEnv(name)
+	if !ok {
+		return "", fmt.Errorf("${%s}: environment variable not set", name)
+	}
+	return v, nil
+}
+
+// Redact replaces the values that Interpolate interpolated in s, as is
+// or URL escaped, with ***.
+func Redact(s string) string {
+	return secrets.replacer().Replace(s)
+}
+
+// secrets are the interpolated values.
+var (
+	secrets   redactor
+	noSecrets = strings.NewReplacer()
+)
+
+type redactor struct {
+	mu     sync.RWMutex
+	values map[string]bool
+	r      *strings.Replacer
+}
+
+func (rd *redactor) add(v string) {
+	if v == "" {
+		return
+	}
+	rd.mu.Lock()
+	defer rd.mu.Unlock()
+	if rd.values == nil {
+		rd.values = make(map[string]bool)
+	}
+	if rd.values[v] {
+		return
+	}
+	for _, form := range []string{v, url.QueryEscape(v), url.PathEscape(v)} {
+		rd.values[form] = true
+	}
+
+	// replace the longest values first so that a value that has
+	// another in it is redacted whole
+	forms := make([]string, 0, len(rd.values))
+	for form := range rd.values {
+		forms = append(forms, form)
+	}
+	sort.Slice(forms, func(i, j int) bool { return len(forms[i]) > len(forms[j]) })
+	pairs := make([]string, 0, 2*len(forms))
+	for _, form := range forms {
+		pairs = append(pairs, form, redacted)
+	}
+	rd.r = strings.NewReplacer(pairs...)
+}
+
+func (rd *redactor) replacer() *strings.Replacer {
+	rd.mu.RLock()
+	defer rd.mu.RUnlock()
+	if rd.r == nil {
+		return noSecrets
+	}
+	return rd.r
+}
+
+// redactError returns err with the interpolated values redacted from
+// its message. The error still wraps err.
+func redactError(err error) error {
+	if err == nil {
+		return nil
+	}
+	msg := Redact(err.Error())
+	if msg == err.Error() {
+		return err
+	}
+	return &redactedError{msg: msg, err: err}
+}
+
+type redactedError struct {
+	msg string
+	err error
+}
+
+func (e *redactedError) Error() string { return e.msg }
+func (e *redactedError) Unwrap() error { return e.err }
+
+// redact the interpolated values from the result's errors and sample,
+// whose endpoint may be the request's URL.
+func (r *Result) redact() {
+	r.Error = redactError(r.Error)
+	r.CheckError = redactError(r.CheckError)
+	if s := r.sample; s != nil {
+		s.Endpoint = Redact(s.Endpoint)
+		s.Body = []byte(Redact(string(s.Body)))
+		s.Reason = Redact(s.Reason)
+		s.Header = redactHeader(s.Header)
+	}
+	for _, step := range r.Steps {
+		step.redact()
+	}
+}
+
+// redactHeader returns a copy of the header with the interpolated
+// values redacted.
+func redactHeader(h http.Header) http.Header {
+	if h == nil {
+		return nil
+	}
+	rh := make(http.Header, len(h))
+	for k, vs := range h {
+		for _, v := range vs {
+			rh[k] = append(rh[k], Redact(v))
+		}
+	}
+	return rh
+}
+
+// interpolate the template's URL, header values and body. The name of
+// the template keeps the references.
+func (t *Template) interpolate() error {
+	var err error
+	if t.URL, err = Interpolate(t.URL); err != nil {
+		return err
+	}
+	if t.Body, err = Interpolate(t.Body); err != nil {
+		return err
+	}
+	if t.Header == nil {
+		return nil
+	}
+	h := make(http.Header, len(t.Header))
+	for k, vs := range t.Header {
+		for _, v := range vs {
+			if v, err = Interpolate(v); err != nil {
+				return err
+			}
+			h[k] = append(h[k], v)
+		}
+	}
+	t.Header = h
+	return nil
+}
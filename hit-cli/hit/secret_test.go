@@ -0,0 +1,136 @@
+package hit
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestInterpolate(t *testing.T) {
+	t.Setenv("HIT_TEST_KEY", "k3y-interpolate")
+	path := filepath.Join(t.TempDir(), "token")
+	if err := os.WriteFile(path, []byte("t0k3n-interpolate\n"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := map[string]struct{ in, want string }{
+		"env":   {"/?key=${HIT_TEST_KEY}", "/?key=k3y-interpolate"},
+		"file":  {"Bearer ${file:" + path + "}", "Bearer t0k3n-interpolate"},
+		"none":  {"/{{key}}", "/{{key}}"},
+		"twice": {"${HIT_TEST_KEY}${HIT_TEST_KEY}", "k3y-interpolatek3y-interpolate"},
+	}
+	for name, tt := range tests {
+		got, err := Interpolate(tt.in)
+		if err != nil {
+			t.Errorf("%s: Interpolate(%q) err=%q; want nil", name, tt.in, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("%s: Interpolate(%q)=%q; want %q", name, tt.in, got, tt.want)
+		}
+	}
+
+	for _, in := range []string{"${HIT_TEST_MISSING}", "${file:" + path + ".missing}"} {
+		if _, err := Interpolate(in); err == nil {
+			t.Errorf("Interpolate(%q) err=nil; want an error", in)
+		}
+	}
+
+	for in, want := range map[string]string{
+		"key=k3y-interpolate":             "key=***",
+		"t0k3n-interpolate and the k3y":   "*** and the k3y",
+		"k3y-interpolatek3y-interpolate!": "******!",
+	} {
+		if got := Redact(in); got != want {
+			t.Errorf("Redact(%q)=%q; want %q", in, got, want)
+		}
+	}
+}
+
+func TestClientRedactsSecrets(t *testing.T) {
+	t.Setenv("HIT_TEST_SECRET", "s3cr3t value")
+
+	// a closed port refuses the requests with their URL in the errors
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	src, err := Mix(Template{URL: "http://" + addr + "/?key=${HIT_TEST_SECRET}"})
+	if err != nil {
+		t.Fatalf("Mix() err=%q; want nil", err)
+	}
+	var errs []error
+	client := &Client{
+		C: 1,
+		OnResult: func(r *Result) {
+			errs = append(errs, r.Error)
+		},
+	}
+	sum := client.Run(context.Background(), src, 2)
+
+	if len(errs) != 2 {
+		t.Fatalf("results=%d; want 2", len(errs))
+	}
+	for _, err := range errs {
+		if err == nil || strings.Contains(err.Error(), "s3cr3t") || !strings.Contains(err.Error(), "key=***") {
+			t.Errorf("err=%v; want the secret redacted", err)
+		}
+		var opErr *net.OpError
+		if !errors.As(err, &opErr) {
+			t.Errorf("err=%v; want it to wrap the original error", err)
+		}
+	}
+	for name := range sum.Endpoints {
+		if strings.Contains(name, "s3cr3t") {
+			t.Errorf("endpoint %q; want the name to keep the reference", name)
+		}
+	}
+
+	// a failed check names its sample after the interpolated URL of a
+	// request without an endpoint
+	server := newTestServer(t, func(http.ResponseWriter, *http.Request) {})
+	u, err := Interpolate(server.URL + "/?key=${HIT_TEST_SECRET}")
+	if err != nil {
+		t.Fatalf("Interpolate() err=%q; want nil", err)
+	}
+	client = &Client{C: 1, Checks: []Check{StatusIn(http.StatusCreated)}}
+	sum = client.Do(context.Background(), newRequest(t, http.MethodGet, u), 1)
+	if len(sum.Samples) != 1 {
+		t.Fatalf("Samples=%d; want 1", len(sum.Samples))
+	}
+	if got := sum.Samples[0].Endpoint; strings.Contains(got, "s3cr3t") || !strings.Contains(got, "key=***") {
+		t.Errorf("sample endpoint %q; want the secret redacted", got)
+	}
+}
+
+func TestTemplateInterpolate(t *testing.T) {
+	t.Setenv("HIT_TEST_TOKEN", "t0k3n-template")
+
+	var got string
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		got = r.Header.Get("Authorization")
+	})
+	src, err := Mix(Template{
+		URL:    server.URL,
+		Header: http.Header{"Authorization": {"Bearer ${HIT_TEST_TOKEN}"}},
+	})
+	if err != nil {
+		t.Fatalf("Mix() err=%q; want nil", err)
+	}
+	(&Client{C: 1}).Run(context.Background(), src, 1)
+	if want := "Bearer t0k3n-template"; got != want {
+		t.Errorf("Authorization=%q; want %q", got, want)
+	}
+
+	if _, err := Mix(Template{URL: "http://go.dev/${HIT_TEST_UNSET}"}); err == nil {
+		t.Error("Mix() err=nil; want an unset variable error")
+	}
+}
@@ -0,0 +1,78 @@
+package hit
+
+import "time"
+
+// Snapshot is a Client's progress during an interval of a run.
+type Snapshot struct {
+	Elapsed  time.Duration // Elapsed is the time since the run started
+	Interval time.Duration // Interval is the length of the interval
+	Requests int           // Requests finished in the interval
+	Errors   int           // Errors in the interval
+	Total    int           // Total requests finished since the run started
+	RPS      float64       // RPS is the requests per second in the interval
+
+	P50, P90, P99 time.Duration
+
+	// Active is the number of requests in flight at the end of the
+	// interval.
+	Active int
+
+	// Statuses counts the requests of the interval by status code. The
+	// requests that failed without a response count as status 0.
+	Statuses map[int]int
+}
+
+// ErrorRate returns the percentage of the requests that failed in the
+// interval.
+func (s Snapshot) ErrorRate() float64 {
+	if s.Requests == 0 {
+		return 0
+	}
+	return float64(s.Errors) / float64(s.Requests) * 100
+}
+
+// interval collects the results of the current interval of a run.
+type interval struct {
+	start, last time.Time
+	total       int
+	errors      int
+	durations   []time.Duration
+	statuses    map[int]int
+}
+
+func newInterval(start time.Time) *interval {
+	return &interval{start: start, last: start, statuses: map[int]int{}}
+}
+
+// add a request's result to the interval.
+func (iv *interval) add(r *Result) {
+	iv.total++
+	iv.durations = append(iv.durations, r.Duration)
+	iv.statuses[r.Status]++
+	if r.failed() {
+		iv.errors++
+	}
+}
+
+// snapshot the interval ending now and start the next one.
+func (iv *interval) snapshot(now time.Time) Snapshot {
+	s := Snapshot{
+		Elapsed:  now.Sub(iv.start),
+		Interval: now.Sub(iv.last),
+		Requests: len(iv.durations),
+		Errors:   iv.errors,
+		Total:    iv.total,
+		P50:      percentile(iv.durations, 50),
+		P90:      percentile(iv.durations, 90),
+		P99:      percentile(iv.durations, 99),
+		Statuses: iv.statuses,
+	}
+	if s.Interval > 0 {
+		s.RPS = float64(s.Requests) / s.Interval.Seconds()
+	}
+	iv.last = now
+	iv.errors = 0
+	iv.durations = iv.durations[:0]
+	iv.statuses = map[int]int{}
+	return s
+}
@@ -0,0 +1,217 @@
+package hit
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Source produces the requests a Client sends.
+type Source interface {
+	// Next returns a new request with the context, or nil if the
+	// source has no more requests.
+	Next(ctx context.Context) *http.Request
+}
+
+// SourceFunc is an adapter to use a function as a Source.
+type SourceFunc func(ctx context.Context) *http.Request
+
+// Next calls f(ctx).
+func (f SourceFunc) Next(ctx context.Context) *http.Request { return f(ctx) }
+
+// Clone returns a Source that produces clones of r.
+//
+// Cloning lets the producer produce identical request values to send
+// HTTP requests to the same URL. If request values were not cloned,
+// there would be clashes with other request values since each request
+// is stateful. https://pkg.go.dev/net/http#Request.Clone
+func Clone(r *http.Request) Source {
+	return SourceFunc(func(ctx context.Context) *http.Request {
+		return r.Clone(ctx)
+	})
+}
+
+// until returns a Source that ends at end, or when src ends. Ending the
+// source, rather than canceling the context, lets the requests in
+// flight finish rather than fail.
+func until(end time.Time, src Source) Source {
+	return SourceFunc(func(ctx context.Context) *http.Request {
+		if time.Now().After(end) {
+			return nil
+		}
+		return src.Next(ctx)
+	})
+}
+
+// Template describes the requests to an endpoint. The URL, header
+// values and body may have {{field}} placeholders filled in with the
+// fields of a Feeder's rows, and ${NAME} or ${file:/path} references
+// that Interpolate replaces.
+type Template struct {
+	Name   string      `json:"name,omitempty"`   // Name of the endpoint in the results; defaults to "METHOD URL"
+	Weight int         `json:"weight,omitempty"` // Weight of the endpoint in a Mix; defaults to 1
+	Method string      `json:"method,omitempty"` // Method defaults to GET
+	URL    string      `json:"url"`
+	Header http.Header `json:"header,omitempty"`
+	Body   string      `json:"body,omitempty"`
+}
+
+// request returns a new request from the template with its
+// placeholders filled in with the fields. The request's context carries
+// the fields for the next steps of a Scenario.
+func (t *Template) request(ctx context.Context, fields Fields) (*http.Request, error) {
+	if fields != nil {
+		ctx = withFields(ctx, fields)
+	}
+	var body io.Reader = http.NoBody
+	if t.Body != "" {
+		body = strings.NewReader(fields.expand(t.Body, noEscape))
+	}
+	r, err := http.NewRequestWithContext(withEndpoint(ctx, t.Name), t.Method, fields.expandURL(t.URL), body)
+	if err != nil {
+		return nil, err
+	}
+	for k, vs := range t.Header {
+		for _, v := range vs {
+			r.Header.Add(k, fields.expand(v, noEscape))
+		}
+	}
+	return r, nil
+}
+
+// init sets the template's defaults and checks that the template makes
+// valid requests whatever its fields are.
+func (t *Template) init() error {
+	if t.Method == "" {
+		t.Method = http.MethodGet
+	}
+	if t.Name == "" {
+		t.Name = t.Method + " " + t.URL
+	}
+	if err := t.interpolate(); err != nil {
+		return fmt.Errorf("%s: %w", t.Name, err)
+	}
+	if t.Weight == 0 {
+		t.Weight = 1
+	}
+	if t.Weight < 0 {
+		return fmt.Errorf("%s: negative weight", t.Name)
+	}
+	check := *t
+	check.URL = placeholder.ReplaceAllString(t.URL, "x")
+	if _, err := check.request(context.Background(), nil); err != nil {
+		return fmt.Errorf("%s: %w", t.Name, err)
+	}
+	return nil
+}
+
+// mix draws requests from templates randomly in proportion to their
+// weights, and fills them in with the rows of an optional feeder.
+type mix struct {
+	templates []Template
+	weights   []int // cumulative weights
+	feeder    *Feeder
+}
+
+// Mix returns a Source that draws each request from one of the templates
+// randomly in proportion to the templates' weights. The results of each
+// template are reported separately in the Result's Endpoints.
+func Mix(templates ...Template) (Source, error) {
+	return Feed(nil, templates...)
+}
+
+// Feed returns a Source like Mix that fills in the placeholders of each
+// request with the next row of the feeder. The source ends when the
+// feeder runs out of rows. A row whose fields make an invalid request
+// fails its request.
+func Feed(feeder *Feeder, templates ...Template) (Source, error) {
+	if len(templates) == 0 {
+		return nil, errors.New("no templates")
+	}
+	m := &mix{
+		feeder:    feeder,
+		templates: make([]Template, len(templates)),
+		weights:   make([]int, len(templates)),
+	}
+	var total int
+	for i, t := range templates {
+		if err := t.init(); err != nil {
+			return nil, err
+		}
+		total += t.Weight
+		m.templates[i] = t
+		m.weights[i] = total
+	}
+	return m, nil
+}
+
+// Next returns a request from a template drawn randomly.
+func (m *mix) Next(ctx context.Context) *http.Request {
+	var fields Fields
+	if m.feeder != nil {
+		var ok bool
+		if fields, ok = m.feeder.next(); !ok {
+			return nil
+		}
+	}
+	w := rand.Intn(m.weights[len(m.weights)-1])
+	i := sort.SearchInts(m.weights, w+1)
+	t := &m.templates[i]
+	r, err := t.request(ctx, fields)
+	if err != nil {
+		// a row's fields can make an invalid request: fail it rather
+		// than end the source
+		return badRequest(withEndpoint(ctx, t.Name), err)
+	}
+	return r
+}
+
+type errorKey struct{}
+
+// badRequest returns a request that fails with err when a Client
+// sends it.
+func badRequest(ctx context.Context, err error) *http.Request {
+	// a request without a URL is always valid
+	r, _ := http.NewRequestWithContext(context.WithValue(ctx, errorKey{}, err), http.MethodGet, "", http.NoBody)
+	return r
+}
+
+// requestError returns the error of a request that badRequest returned,
+// if any.
+func requestError(ctx context.Context) error {
+	err, _ := ctx.Value(errorKey{}).(error)
+	return err
+}
+
+type endpointKey struct{}
+
+// withEndpoint returns a context that names the endpoint of a request.
+func withEndpoint(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, endpointKey{}, name)
+}
+
+// endpoint returns the name of a request's endpoint, if any.
+func endpoint(ctx context.Context) string {
+	name, _ := ctx.Value(endpointKey{}).(string)
+	return name
+}
+
+type fieldsKey struct{}
+
+// withFields returns a context that carries the fields a request was
+// filled in with.
+func withFields(ctx context.Context, fields Fields) context.Context {
+	return context.WithValue(ctx, fieldsKey{}, fields)
+}
+
+// fields returns the fields a request was filled in with, if any.
+func fields(ctx context.Context) Fields {
+	f, _ := ctx.Value(fieldsKey{}).(Fields)
+	return f
+}
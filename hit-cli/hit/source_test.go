@@ -0,0 +1,80 @@
+package hit
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"sync"
+	"testing"
+)
+
+func TestClientRunMix(t *testing.T) {
+	t.Parallel()
+
+	const n = 1000
+
+	var (
+		mu     sync.Mutex
+		bodies = map[string]string{}
+		server = newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			b, _ := io.ReadAll(r.Body)
+			mu.Lock()
+			bodies[r.Method+" "+r.URL.Path] = string(b)
+			mu.Unlock()
+			if r.Method == http.MethodPost {
+				w.WriteHeader(http.StatusCreated)
+			}
+		})
+	)
+
+	src, err := Mix(
+		Template{Name: "resolve", Weight: 9, URL: server.URL + "/r/key"},
+		Template{Name: "shorten", Weight: 1, Method: http.MethodPost, URL: server.URL + "/s",
+			Header: http.Header{"Content-Type": {"application/json"}},
+			Body:   `{"url":"https://go.dev"}`},
+	)
+	if err != nil {
+		t.Fatalf("Mix() err=%q; want nil", err)
+	}
+
+	c := &Client{C: 4}
+	sum := c.Run(context.Background(), src, n)
+	if sum.Requests != n || sum.Errors != 0 {
+		t.Fatalf("Requests=%d, Errors=%d; want %d, 0", sum.Requests, sum.Errors, n)
+	}
+	if got := len(sum.Endpoints); got != 2 {
+		t.Fatalf("Endpoints=%d; want 2", got)
+	}
+	resolve, shorten := sum.Endpoints["resolve"], sum.Endpoints["shorten"]
+	if resolve.Requests+shorten.Requests != n {
+		t.Errorf("endpoint requests=%d+%d; want %d", resolve.Requests, shorten.Requests, n)
+	}
+	// 10% of the requests should be POSTs, give or take.
+	if got := shorten.Requests; got < n/20 || got > n/5 {
+		t.Errorf("shorten requests=%d; want about %d", got, n/10)
+	}
+	if got, want := bodies["POST /s"], `{"url":"https://go.dev"}`; got != want {
+		t.Errorf("POST body=%q; want %q", got, want)
+	}
+}
+
+func TestMixErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string][]Template{
+		"empty":  nil,
+		"url":    {{URL: "://foo"}},
+		"method": {{Method: "BAD METHOD", URL: "http://foo"}},
+		"weight": {{URL: "http://foo", Weight: -1}},
+	}
+	for name, templates := range tests {
+		templates := templates
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := Mix(templates...); err == nil {
+				t.Error("Mix() err=nil; want err")
+			}
+		})
+	}
+}
@@ -0,0 +1,102 @@
+package hit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+)
+
+// Stage is a step of a load profile. The request rate, or the number of
+// concurrent workers, changes linearly from the previous stage's target
+// to this stage's Target over Duration. The first stage ramps up from
+// zero.
+type Stage struct {
+	Duration time.Duration
+	Target   int // Target is the requests per second, or workers, at the end of the stage
+}
+
+// Stages is a load profile made of consecutive stages, such as a
+// ramp-up, a hold and a ramp-down.
+type Stages []Stage
+
+// Duration returns the total duration of the stages.
+func (s Stages) Duration() time.Duration {
+	var d time.Duration
+	for _, st := range s {
+		d += st.Duration
+	}
+	return d
+}
+
+// Rate returns the requests per second, or workers, the stages describe
+// after elapsed time. It returns the last stage's target after the stages end.
+func (s Stages) Rate(elapsed time.Duration) float64 {
+	var from float64
+	for _, st := range s {
+		to := float64(st.Target)
+		if elapsed < st.Duration {
+			return from + (to-from)*float64(elapsed)/float64(st.Duration)
+		}
+		elapsed -= st.Duration
+		from = to
+	}
+	return from
+}
+
+// Max returns the highest target of the stages.
+func (s Stages) Max() int {
+	var max int
+	for _, st := range s {
+		if st.Target > max {
+			max = st.Target
+		}
+	}
+	return max
+}
+
+// StageMode is what the targets of a load profile's stages are.
+type StageMode int
+
+// Stage modes.
+const (
+	// StageRPS stages target requests per second.
+	StageRPS StageMode = iota
+	// StageConcurrency stages target a number of concurrent workers.
+	// The workers send requests as fast as they can.
+	StageConcurrency
+)
+
+// ParseStageMode parses a stage mode name: rps or concurrency.
+func ParseStageMode(s string) (StageMode, error) {
+	switch s {
+	case "rps":
+		return StageRPS, nil
+	case "concurrency":
+		return StageConcurrency, nil
+	}
+	return 0, fmt.Errorf("unknown stage mode %q", s)
+}
+
+func (m StageMode) String() string {
+	if m == StageConcurrency {
+		return "concurrency"
+	}
+	return "rps"
+}
+
+// gate returns a function that makes the worker with the id wait until
+// the stages, started at start, need at least id concurrent workers. It
+// stops making workers wait when the stages end or ctx is done.
+func (s Stages) gate(ctx context.Context, start time.Time) func(id int) {
+	end := start.Add(s.Duration())
+	return func(id int) {
+		for {
+			now := time.Now()
+			if ctx.Err() != nil || !now.Before(end) || float64(id) <= math.Ceil(s.Rate(now.Sub(start))) {
+				return
+			}
+			time.Sleep(rampWait)
+		}
+	}
+}
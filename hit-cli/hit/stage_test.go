@@ -0,0 +1,133 @@
+package hit
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestStagesRate(t *testing.T) {
+	t.Parallel()
+
+	stages := Stages{
+		{Duration: 10 * time.Second, Target: 100},
+		{Duration: 20 * time.Second, Target: 100},
+		{Duration: 10 * time.Second, Target: 0},
+	}
+	tests := map[string]struct {
+		elapsed time.Duration
+		want    float64
+	}{
+		"start":       {0, 0},
+		"ramp-up":     {5 * time.Second, 50},
+		"hold":        {20 * time.Second, 100},
+		"ramp-down":   {35 * time.Second, 50},
+		"after-end":   {time.Minute, 0},
+		"stage-start": {10 * time.Second, 100},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if got := stages.Rate(tt.elapsed); got != tt.want {
+				t.Errorf("Rate(%s)=%.1f; want %.1f", tt.elapsed, got, tt.want)
+			}
+		})
+	}
+	if got, want := stages.Duration(), 40*time.Second; got != want {
+		t.Errorf("Duration()=%s; want %s", got, want)
+	}
+}
+
+func TestClientDoStages(t *testing.T) {
+	t.Parallel()
+
+	var (
+		gotHits atomic.Int64
+		server  = newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			gotHits.Add(1)
+		})
+		request = newRequest(t, http.MethodGet, server.URL)
+	)
+
+	c := &Client{
+		C: 10,
+		Stages: Stages{
+			{Duration: 200 * time.Millisecond, Target: 100},
+			{Duration: 200 * time.Millisecond, Target: 100},
+		},
+	}
+
+	// ~10 requests while ramping up and ~20 requests while holding.
+	const min, max = 15, 45
+	sum := c.Do(context.Background(), request, 0)
+	if got := gotHits.Load(); got < min || got > max {
+		t.Errorf("hits=%d; want between %d and %d", got, min, max)
+	}
+	if got := sum.Requests; int64(got) != gotHits.Load() {
+		t.Errorf("Requests=%d; want %d", got, gotHits.Load())
+	}
+}
+
+func TestClientDoStagesConcurrency(t *testing.T) {
+	t.Parallel()
+
+	var (
+		inflight, most atomic.Int64
+		server         = newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			n := inflight.Add(1)
+			defer inflight.Add(-1)
+			for m := most.Load(); n > m && !most.CompareAndSwap(m, n); m = most.Load() {
+			}
+			time.Sleep(5 * time.Millisecond)
+		})
+		request = newRequest(t, http.MethodGet, server.URL)
+	)
+
+	c := &Client{
+		C:         100, // the stages override it
+		StageMode: StageConcurrency,
+		Stages: Stages{
+			{Duration: 100 * time.Millisecond, Target: 3},
+			{Duration: 200 * time.Millisecond, Target: 3},
+		},
+	}
+	start := time.Now()
+	sum := c.Do(context.Background(), request, 0)
+	if got := most.Load(); got != 3 {
+		t.Errorf("most concurrent requests=%d; want 3 workers", got)
+	}
+	if sum.Requests == 0 || sum.Errors > 0 {
+		t.Errorf("Requests=%d, Errors=%d; want requests without errors", sum.Requests, sum.Errors)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("run took %s; want it to end with the stages", elapsed)
+	}
+
+	// the waiting workers stop waiting when the requests run out
+	c.Stages = Stages{{Duration: time.Minute, Target: 10}}
+	start = time.Now()
+	if sum = c.Do(context.Background(), request, 5); sum.Requests != 5 {
+		t.Errorf("Requests=%d; want 5", sum.Requests)
+	}
+	if elapsed := time.Since(start); elapsed > 10*time.Second {
+		t.Errorf("run took %s; want it to end when the requests run out", elapsed)
+	}
+}
+
+func TestParseStageMode(t *testing.T) {
+	t.Parallel()
+
+	for _, name := range []string{"rps", "concurrency"} {
+		m, err := ParseStageMode(name)
+		if err != nil || m.String() != name {
+			t.Errorf("ParseStageMode(%q)=%s, %v; want %s", name, m, err, name)
+		}
+	}
+	if _, err := ParseStageMode("vus"); err == nil {
+		t.Error(`ParseStageMode("vus") err=nil; want an error`)
+	}
+}
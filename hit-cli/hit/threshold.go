@@ -0,0 +1,153 @@
+package hit
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Threshold is a pass or fail condition on a metric of an aggregated
+// result, such as p99<200ms, errors<1% or rps>=500.
+//
+// The metrics are the latency percentiles (p50, p99.9, ...), fastest
+// and slowest in milliseconds, errors and failed checks as percentages
+// (with %) or counts, rps and requests.
+type Threshold struct {
+	Metric string
+	Op     string  // Op is one of <, <=, >, >= and ==
+	Value  float64 // Value is in milliseconds for latencies
+	Rate   bool    // Rate compares errors as a percentage
+}
+
+// ThresholdResult is a threshold evaluated against a result.
+type ThresholdResult struct {
+	Threshold
+	Actual float64
+	Pass   bool
+}
+
+var (
+	thresholdExpr  = regexp.MustCompile(`^\s*([a-z]+|p\d+(?:\.\d+)?)\s*(<=|>=|==|<|>)\s*(\S+)\s*$`)
+	percentileExpr = regexp.MustCompile(`^p\d+(?:\.\d+)?$`)
+)
+
+// ParseThreshold parses a threshold expression such as p99<200ms.
+func ParseThreshold(s string) (Threshold, error) {
+	m := thresholdExpr.FindStringSubmatch(s)
+	if m == nil {
+		return Threshold{}, fmt.Errorf("threshold %q: want metric, operator and value", s)
+	}
+	t := Threshold{Metric: m[1], Op: m[2]}
+	v := m[3]
+	var err error
+	switch {
+	case percentileExpr.MatchString(t.Metric):
+		if p, _ := strconv.ParseFloat(t.Metric[1:], 64); p > 100 {
+			return Threshold{}, fmt.Errorf("threshold %q: percentile is over 100", s)
+		}
+		t.Value, err = parseMillis(v)
+	case t.latency():
+		t.Value, err = parseMillis(v)
+	case t.Metric == "errors", t.Metric == "checks":
+		t.Rate = strings.HasSuffix(v, "%")
+		t.Value, err = strconv.ParseFloat(strings.TrimSuffix(v, "%"), 64)
+	case t.Metric == "rps", t.Metric == "requests":
+		t.Value, err = strconv.ParseFloat(v, 64)
+	default:
+		return Threshold{}, fmt.Errorf("threshold %q: unknown metric %q", s, t.Metric)
+	}
+	if err != nil {
+		return Threshold{}, fmt.Errorf("threshold %q: invalid value %q", s, v)
+	}
+	return t, nil
+}
+
+// parseMillis parses a duration such as 200ms, or a number of
+// milliseconds, into milliseconds.
+func parseMillis(s string) (float64, error) {
+	if d, err := time.ParseDuration(s); err == nil {
+		return ms(d), nil
+	}
+	return strconv.ParseFloat(s, 64)
+}
+
+func (t Threshold) latency() bool {
+	return t.Metric == "fastest" || t.Metric == "slowest" || percentileExpr.MatchString(t.Metric)
+}
+
+func (t Threshold) String() string {
+	v := strconv.FormatFloat(t.Value, 'f', -1, 64)
+	switch {
+	case t.latency():
+		v += "ms"
+	case t.Rate:
+		v += "%"
+	}
+	return t.Metric + t.Op + v
+}
+
+// Eval evaluates the threshold against a finalized result.
+func (t Threshold) Eval(r *Result) ThresholdResult {
+	tr := ThresholdResult{Threshold: t, Actual: t.actual(r)}
+	a, v := tr.Actual, t.Value
+	switch t.Op {
+	case "<":
+		tr.Pass = a < v
+	case "<=":
+		tr.Pass = a <= v
+	case ">":
+		tr.Pass = a > v
+	case ">=":
+		tr.Pass = a >= v
+	case "==":
+		tr.Pass = a == v
+	}
+	return tr
+}
+
+func (t Threshold) actual(r *Result) float64 {
+	switch t.Metric {
+	case "fastest":
+		return ms(r.Fastest)
+	case "slowest":
+		return ms(r.Slowest)
+	case "errors":
+		if t.Rate {
+			return rate(r.Errors, r.Requests)
+		}
+		return float64(r.Errors)
+	case "checks":
+		if t.Rate {
+			return rate(r.CheckFailures, r.Requests)
+		}
+		return float64(r.CheckFailures)
+	case "rps":
+		return r.RPS
+	case "requests":
+		return float64(r.Requests)
+	}
+	p, _ := strconv.ParseFloat(t.Metric[1:], 64)
+	return ms(percentile(r.latencies, p))
+}
+
+func (tr ThresholdResult) String() string {
+	actual := strconv.FormatFloat(tr.Actual, 'f', 2, 64)
+	switch {
+	case tr.latency():
+		actual += "ms"
+	case tr.Rate:
+		actual += "%"
+	}
+	return fmt.Sprintf("%s (%s=%s)", tr.Threshold, tr.Metric, actual)
+}
+
+// rate returns n as a percentage of total. It returns 100 if total is
+// zero to fail the thresholds of a run without requests.
+func rate(n, total int) float64 {
+	if total == 0 {
+		return 100
+	}
+	return float64(n) / float64(total) * 100
+}
@@ -0,0 +1,51 @@
+package hit
+
+import (
+	"testing"
+)
+
+func TestThresholdEval(t *testing.T) {
+	t.Parallel()
+
+	sum := newTestResult() // 4 requests, 2 errors, 10ms-40ms, 4 rps
+	tests := map[string]bool{
+		"p50<=20ms":      true,
+		"p99<40ms":       false,
+		"p75 < 35":       true,
+		"fastest>=10ms":  true,
+		"slowest<0.04s":  false,
+		"errors<60%":     true,
+		"errors<50%":     false,
+		"errors==2":      true,
+		"rps>=4":         true,
+		"requests>4":     false,
+		"p99.9<=40ms":    true,
+		"errors > 10.5%": true,
+	}
+	for expr, want := range tests {
+		expr, want := expr, want
+		t.Run(expr, func(t *testing.T) {
+			t.Parallel()
+
+			th, err := ParseThreshold(expr)
+			if err != nil {
+				t.Fatalf("ParseThreshold(%q) err=%q; want nil", expr, err)
+			}
+			if got := th.Eval(sum); got.Pass != want {
+				t.Errorf("Eval()=%s pass=%t; want %t", got, got.Pass, want)
+			}
+		})
+	}
+}
+
+func TestParseThresholdErrors(t *testing.T) {
+	t.Parallel()
+
+	for _, expr := range []string{
+		"", "p99", "p99<", "latency<1s", "p99<fast", "p101<1s", "rps=>5", "errors<x%",
+	} {
+		if _, err := ParseThreshold(expr); err == nil {
+			t.Errorf("ParseThreshold(%q) err=nil; want err", expr)
+		}
+	}
+}
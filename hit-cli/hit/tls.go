@@ -0,0 +1,65 @@
+package hit
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// TLSOptions configures the TLS connections to HTTPS targets.
+type TLSOptions struct {
+	CAFile     string // CAFile is a PEM bundle of CAs to trust
+	CertFile   string // CertFile is a PEM client certificate for mTLS
+	KeyFile    string // KeyFile is the client certificate's PEM key
+	Insecure   bool   // Insecure skips verifying the server certificate
+	MinVersion uint16 // MinVersion is the minimum TLS version, like tls.VersionTLS12
+	ServerName string // ServerName overrides the SNI and verified host name
+}
+
+// Config returns a TLS configuration with the options.
+func (o TLSOptions) Config() (*tls.Config, error) {
+	cfg := &tls.Config{
+		InsecureSkipVerify: o.Insecure,
+		MinVersion:         o.MinVersion,
+		ServerName:         o.ServerName,
+	}
+	if o.CAFile != "" {
+		pem, err := os.ReadFile(o.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("read CA bundle: %w", err)
+		}
+		cfg.RootCAs = x509.NewCertPool()
+		if !cfg.RootCAs.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("%s: no PEM certificates", o.CAFile)
+		}
+	}
+	if (o.CertFile == "") != (o.KeyFile == "") {
+		return nil, errors.New("client certificate and key should be set together")
+	}
+	if o.CertFile != "" {
+		cert, err := tls.LoadX509KeyPair(o.CertFile, o.KeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("load client certificate: %w", err)
+		}
+		cfg.Certificates = []tls.Certificate{cert}
+	}
+	return cfg, nil
+}
+
+// ParseTLSVersion converts a TLS version such as 1.2 to its
+// crypto/tls constant.
+func ParseTLSVersion(s string) (uint16, error) {
+	switch s {
+	case "1.0":
+		return tls.VersionTLS10, nil
+	case "1.1":
+		return tls.VersionTLS11, nil
+	case "1.2":
+		return tls.VersionTLS12, nil
+	case "1.3":
+		return tls.VersionTLS13, nil
+	}
+	return 0, fmt.Errorf("unknown TLS version %q", s)
+}
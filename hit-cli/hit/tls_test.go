@@ -0,0 +1,113 @@
+package hit
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClientDoTLS(t *testing.T) {
+	t.Parallel()
+
+	var sni atomic.Value
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sni.Store(r.TLS.ServerName)
+	}))
+	server.TLS = &tls.Config{ClientAuth: tls.RequireAnyClientCert}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+
+	// the test server's certificate is also the client certificate
+	dir := t.TempDir()
+	cert := server.TLS.Certificates[0]
+	key, err := x509.MarshalPKCS8PrivateKey(cert.PrivateKey)
+	if err != nil {
+		t.Fatalf("MarshalPKCS8PrivateKey() err=%q; want nil", err)
+	}
+	var (
+		certFile = writePEM(t, dir, "cert.pem", "CERTIFICATE", cert.Certificate[0])
+		keyFile  = writePEM(t, dir, "key.pem", "PRIVATE KEY", key)
+	)
+
+	tests := map[string]struct {
+		opts       TLSOptions
+		wantErrors int
+		wantSNI    string
+	}{
+		"ca/mtls": {
+			opts: TLSOptions{CAFile: certFile, CertFile: certFile, KeyFile: keyFile},
+		},
+		"insecure/mtls": {
+			opts: TLSOptions{Insecure: true, CertFile: certFile, KeyFile: keyFile},
+		},
+		"sni": {
+			opts: TLSOptions{
+				CAFile: certFile, CertFile: certFile, KeyFile: keyFile,
+				ServerName: "example.com", MinVersion: tls.VersionTLS13,
+			},
+			wantSNI: "example.com",
+		},
+		"untrusted": {
+			opts:       TLSOptions{CertFile: certFile, KeyFile: keyFile},
+			wantErrors: 1,
+		},
+		"no-client-cert": {
+			opts:       TLSOptions{CAFile: certFile},
+			wantErrors: 1,
+		},
+	}
+	for name, tt := range tests {
+		tt := tt
+		t.Run(name, func(t *testing.T) {
+			cfg, err := tt.opts.Config()
+			if err != nil {
+				t.Fatalf("Config() err=%q; want nil", err)
+			}
+			c := &Client{C: 1, TLS: cfg}
+			sum := c.Do(context.Background(), newRequest(t, http.MethodGet, server.URL), 1)
+			if sum.Errors != tt.wantErrors {
+				t.Errorf("Errors=%d; want %d", sum.Errors, tt.wantErrors)
+			}
+			if got, _ := sni.Load().(string); tt.wantSNI != "" && got != tt.wantSNI {
+				t.Errorf("ServerName=%q; want %q", got, tt.wantSNI)
+			}
+		})
+	}
+}
+
+func TestTLSOptionsConfigErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := map[string]TLSOptions{
+		"ca/missing":   {CAFile: "missing.pem"},
+		"cert/missing": {CertFile: "missing.pem", KeyFile: "missing.pem"},
+		"cert/no-key":  {CertFile: "cert.pem"},
+	}
+	for name, opts := range tests {
+		opts := opts
+		t.Run(name, func(t *testing.T) {
+			t.Parallel()
+
+			if _, err := opts.Config(); err == nil {
+				t.Error("Config() err=nil; want err")
+			}
+		})
+	}
+}
+
+func writePEM(tb testing.TB, dir, name, typ string, der []byte) string {
+	tb.Helper()
+	path := filepath.Join(dir, name)
+	data := pem.EncodeToMemory(&pem.Block{Type: typ, Bytes: der})
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		tb.Fatalf("WriteFile(%q) err=%q; want nil", path, err)
+	}
+	return path
+}
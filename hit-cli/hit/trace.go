@@ -0,0 +1,114 @@
+package hit
+
+import (
+	"context"
+	"crypto/tls"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Phases are the durations of the phases of an HTTP request. A request
+// sent over a reused connection skips the DNS, Connect and TLS phases.
+type Phases struct {
+	DNS      time.Duration // DNS is the DNS lookup duration
+	Connect  time.Duration // Connect is the TCP connection duration
+	TLS      time.Duration // TLS is the TLS handshake duration
+	TTFB     time.Duration // TTFB is the time to the response's first byte
+	Transfer time.Duration // Transfer is the response body's read duration
+}
+
+// add o's phase durations to p.
+func (p *Phases) add(o Phases) {
+	p.DNS += o.DNS
+	p.Connect += o.Connect
+	p.TLS += o.TLS
+	p.TTFB += o.TTFB
+	p.Transfer += o.Transfer
+}
+
+// div divides p's phase durations by n.
+func (p Phases) div(n int) Phases {
+	if n == 0 {
+		return Phases{}
+	}
+	d := time.Duration(n)
+	return Phases{
+		DNS:      p.DNS / d,
+		Connect:  p.Connect / d,
+		TLS:      p.TLS / d,
+		TTFB:     p.TTFB / d,
+		Transfer: p.Transfer / d,
+	}
+}
+
+// tracer records the phases of a request. Its hooks may be called
+// concurrently, for example, when dialing multiple addresses.
+type tracer struct {
+	mu sync.Mutex
+
+	start, dnsStart, connectStart, tlsStart time.Time
+	firstByte                               time.Time
+	phases                                  Phases
+	conn, reused                            bool
+}
+
+// trace starts tracing a request and returns ctx with the trace hooks.
+func (t *tracer) trace(ctx context.Context) context.Context {
+	t.start = time.Now()
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.set(&t.dnsStart)
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.since(&t.phases.DNS, &t.dnsStart)
+		},
+		ConnectStart: func(string, string) {
+			t.set(&t.connectStart)
+		},
+		ConnectDone: func(string, string, error) {
+			t.since(&t.phases.Connect, &t.connectStart)
+		},
+		TLSHandshakeStart: func() {
+			t.set(&t.tlsStart)
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.since(&t.phases.TLS, &t.tlsStart)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			t.conn = true
+			t.reused = info.Reused
+			t.mu.Unlock()
+		},
+		GotFirstResponseByte: func() {
+			t.set(&t.firstByte)
+			t.since(&t.phases.TTFB, &t.start)
+		},
+	})
+}
+
+// done finishes tracing a request that ended at end. It reports the
+// phases, and whether the request got a new or a reused connection.
+func (t *tracer) done(end time.Time) (phases Phases, newConn, reused bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if !t.firstByte.IsZero() {
+		t.phases.Transfer = end.Sub(t.firstByte)
+	}
+	return t.phases, t.conn && !t.reused, t.reused
+}
+
+// set the time to now.
+func (t *tracer) set(tm *time.Time) {
+	t.mu.Lock()
+	*tm = time.Now()
+	t.mu.Unlock()
+}
+
+// since sets d to the time elapsed since start.
+func (t *tracer) since(d *time.Duration, start *time.Time) {
+	t.mu.Lock()
+	*d = time.Since(*start)
+	t.mu.Unlock()
+}
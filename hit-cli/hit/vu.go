@@ -0,0 +1,106 @@
+package hit
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/cookiejar"
+	"time"
+)
+
+// VU is a virtual user. Each of the Client's workers is a virtual user
+// that sends its requests with its own session: cookies, headers and
+// variables.
+type VU struct {
+	ID int // ID numbers the virtual users from 1
+
+	// Jar keeps the virtual user's cookies. It is nil unless the
+	// Client has Sessions.
+	Jar http.CookieJar
+
+	// Header is added to the virtual user's requests that do not set
+	// the same headers.
+	Header http.Header
+
+	// Vars fill in the placeholders of the virtual user's scenario
+	// iterations, along with the feeder's fields.
+	Vars Fields
+
+	transport http.RoundTripper
+	timeout   time.Duration
+}
+
+// Sessions gives each virtual user its own cookie jar, and calls setup
+// with each virtual user before it sends requests. Setup may be nil.
+func Sessions(setup func(context.Context, *VU) error) Option {
+	return func(c *Client) {
+		c.Sessions = true
+		c.Setup = setup
+	}
+}
+
+// Do sends an HTTP request with the virtual user's session and returns
+// the response. Its performance is not measured, which makes it fit to
+// log in from the Client's Setup.
+func (vu *VU) Do(r *http.Request) (*http.Response, error) {
+	return vu.client().Do(vu.prepare(r))
+}
+
+// prepare adds the virtual user's headers to a request.
+func (vu *VU) prepare(r *http.Request) *http.Request {
+	if len(vu.Header) == 0 {
+		return r
+	}
+	if r.Header == nil {
+		r.Header = http.Header{}
+	}
+	for k, v := range vu.Header {
+		if _, ok := r.Header[k]; !ok {
+			r.Header[k] = v
+		}
+	}
+	return r
+}
+
+func (vu *VU) client() *http.Client {
+	return &http.Client{
+		Timeout:   vu.timeout,
+		Transport: vu.transport,
+		Jar:       vu.Jar,
+	}
+}
+
+// vu returns the virtual user numbered id. Its requests fail with the
+// setup's error if the setup fails.
+func (c *Client) vu(ctx context.Context, id int, transport http.RoundTripper) (*VU, error) {
+	vu := &VU{
+		ID:        id,
+		Header:    http.Header{},
+		Vars:      Fields{},
+		transport: transport,
+		timeout:   c.Timeout,
+	}
+	if c.Sessions {
+		// cookiejar.New never fails without options
+		vu.Jar, _ = cookiejar.New(nil)
+	}
+	if c.Setup == nil {
+		return vu, nil
+	}
+	if err := c.Setup(ctx, vu); err != nil {
+		return vu, fmt.Errorf("setup: %w", err)
+	}
+	return vu, nil
+}
+
+// fail returns a function that fails each request with err.
+func fail(err error) SendFunc {
+	return func(r *http.Request) *Result {
+		return &Result{
+			Start:    time.Now(),
+			Error:    err,
+			Endpoint: endpoint(r.Context()),
+			Attempts: 1,
+		}
+	}
+}
@@ -0,0 +1,119 @@
+package hit
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+func TestClientSessions(t *testing.T) {
+	t.Parallel()
+
+	const (
+		n = 20
+		c = 4
+	)
+
+	var (
+		mu     sync.Mutex
+		users  = map[string]int{}
+		server = newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path == "/login" {
+				http.SetCookie(w, &http.Cookie{
+					Name:  "session",
+					Value: r.Header.Get("X-User"),
+				})
+				return
+			}
+			cookie, err := r.Cookie("session")
+			if err != nil || cookie.Value != r.Header.Get("X-User") {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			mu.Lock()
+			users[cookie.Value]++
+			mu.Unlock()
+		})
+	)
+
+	login := func(ctx context.Context, vu *VU) error {
+		vu.Header.Set("X-User", strconv.Itoa(vu.ID))
+		r, err := http.NewRequestWithContext(ctx, http.MethodPost, server.URL+"/login", http.NoBody)
+		if err != nil {
+			return err
+		}
+		response, err := vu.Do(r)
+		if err != nil {
+			return err
+		}
+		return response.Body.Close()
+	}
+
+	var client Client
+	Concurrency(c)(&client)
+	Sessions(login)(&client)
+	sum := client.Do(context.Background(), newRequest(t, http.MethodGet, server.URL), n)
+	if got := sum.Requests; got != n {
+		t.Errorf("Requests=%d; want %d", got, n)
+	}
+	if got := sum.Errors; got != 0 {
+		t.Errorf("Errors=%d; want 0", got)
+	}
+	if got := len(users); got != c {
+		t.Errorf("users=%d; want %d", got, c)
+	}
+	if got := client.Do(context.Background(), newRequest(t, http.MethodGet, server.URL), n); got.Requests != n {
+		t.Errorf("second run Requests=%d; want %d", got.Requests, n)
+	}
+}
+
+func TestClientSessionsWithoutJar(t *testing.T) {
+	t.Parallel()
+
+	server := newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+		if _, err := r.Cookie("session"); err != nil {
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	})
+	client := &Client{
+		C: 2,
+		Setup: func(ctx context.Context, vu *VU) error {
+			if vu.Jar != nil {
+				return errors.New("jar without sessions")
+			}
+			return nil
+		},
+	}
+	sum := client.Do(context.Background(), newRequest(t, http.MethodGet, server.URL), 4)
+	if got := sum.Errors; got != 4 {
+		t.Errorf("Errors=%d; want 4", got)
+	}
+}
+
+func TestClientSetupError(t *testing.T) {
+	t.Parallel()
+
+	var (
+		sent   sync.Map
+		server = newTestServer(t, func(w http.ResponseWriter, r *http.Request) {
+			sent.Store(r.URL.Path, true)
+		})
+		errSetup = errors.New("bad credentials")
+		client   = &Client{
+			C: 2,
+			Setup: func(context.Context, *VU) error {
+				return errSetup
+			},
+		}
+	)
+	sum := client.Do(context.Background(), newRequest(t, http.MethodGet, server.URL), 4)
+	if got := sum.Errors; got != 4 {
+		t.Errorf("Errors=%d; want 4", got)
+	}
+	if _, ok := sent.Load("/"); ok {
+		t.Error("sent a request; want none after a failed setup")
+	}
+}